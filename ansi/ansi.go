@@ -22,23 +22,63 @@ const (
 	CTRL_E               = "\x05"
 	CTRL_W               = "\x17"
 	CTRL_Y               = "\x19"
+	CTRL_R               = "\x12"
+	CTRL_G               = "\x07"
+	CTRL_O               = "\x0f"
+	CTRL_SPACE           = "\x00"
+	CTRL_V               = "\x16"
+	TAB                  = "\t"
+
+	CTRL_RIGHT_BRACKET = "\x1d"
+
+	CTRL_X_CTRL_E      = "\x18\x05"
+	CTRL_X_OPEN_PAREN  = "\x18("
+	CTRL_X_CLOSE_PAREN = "\x18)"
+	CTRL_X_E           = "\x18e"
+	CTRL_X_CTRL_X      = "\x18\x18"
 
 	META_B     = "\x1bb"
 	META_LEFT  = "\x1bB"
 	META_F     = "\x1bf"
 	META_RIGHT = "\x1bF"
+	META_CARET = "\x1b^"
+	META_DOT   = "\x1b."
+	META_UNDER = "\x1b_"
+	META_U     = "\x1bu"
+	META_L     = "\x1bl"
+	META_C     = "\x1bc"
+	META_T     = "\x1bt"
+	META_W     = "\x1bw"
+	META_MINUS = "\x1b-"
+	META_0     = "\x1b0"
+	META_1     = "\x1b1"
+	META_2     = "\x1b2"
+	META_3     = "\x1b3"
+	META_4     = "\x1b4"
+	META_5     = "\x1b5"
+	META_6     = "\x1b6"
+	META_7     = "\x1b7"
+	META_8     = "\x1b8"
+	META_9     = "\x1b9"
+
+	META_CTRL_RIGHT_BRACKET = "\x1b\x1d"
 
 	LEFT  = "\x1b[D"
 	RIGHT = "\x1b[C"
 	UP    = "\x1b[A"
 	DOWN  = "\x1b[B"
 
-	DELETE = "\x1b[3\x7e"
+	SHIFT_TAB = "\x1b[Z"
+
+	DELETE    = "\x1b[3\x7e"
+	PAGE_UP   = "\x1b[5\x7e"
+	PAGE_DOWN = "\x1b[6\x7e"
 )
 
 // Partial codes (beginning of a potentially valid ANSI code)
 const (
 	START_ESCAPE_SEQ            Code = "\x1b"
+	CTRL_X                           = "\x18"
 	START_EXTENDED_ESCAPE_SEQ        = "\x1b["
 	START_EXTENDED_ESCAPE_SEQ_0      = "\x1b[0"
 	START_EXTENDED_ESCAPE_SEQ_1      = "\x1b[1"
@@ -59,4 +99,10 @@ const (
 	EraseToRight           = "\x1b[K"
 	ClearScreen            = "\x1b[H\x1b[2J"
 	MoveCursorForward      = "\x1b[0G\x1b[%dC" // format string expecting an integer (%d)
+	MoveCursorUp           = "\x1b[%dA"        // format string expecting a positive integer (%d)
+	EraseDown              = "\x1b[J"
+	Bold                   = "\x1b[1m"
+	Dim                    = "\x1b[2m"
+	Reverse                = "\x1b[7m"
+	ResetAttributes        = "\x1b[0m"
 )
@@ -0,0 +1,44 @@
+package uniline
+
+// CharacterSearch reads the next key and moves the cursor to the next occurrence of its character in the line.
+// With a numeric argument n (c.f. Count), the cursor moves to the nth occurrence, backward if n is negative.
+func (core *Core) CharacterSearch() {
+	core.characterSearch(core.Count())
+}
+
+// CharacterSearchBackward is CharacterSearch, moving the cursor to the previous occurrence.
+func (core *Core) CharacterSearchBackward() {
+	core.characterSearch(-core.Count())
+}
+
+// characterSearch moves the cursor to the nth occurrence of the character of the next key, backward if n is negative.
+func (core *Core) characterSearch(n int) {
+	key, ok := core.readKey()
+	if !ok {
+		return
+	}
+	r, ok := printable(key)
+	if !ok {
+		core.Bell()
+		return
+	}
+	i := core.pos.runes
+	for ; n > 0; n-- {
+		for i++; i < len(core.buf.chars) && core.buf.chars[i].r != r; i++ {
+		}
+		if i >= len(core.buf.chars) {
+			core.Bell()
+			return
+		}
+	}
+	for ; n < 0; n++ {
+		for i--; i >= 0 && core.buf.chars[i].r != r; i-- {
+		}
+		if i < 0 {
+			core.Bell()
+			return
+		}
+	}
+	core.pos = core.buf.RunePosition(i)
+	core.Refresh()
+}
@@ -0,0 +1,32 @@
+package uniline_test
+
+import (
+	"testing"
+
+	"github.com/tiborvass/uniline"
+	"github.com/tiborvass/uniline/ansi"
+)
+
+func TestCharacterSearch(t *testing.T) {
+	for _, tc := range []struct {
+		keys []ansi.Code
+		want string
+	}{
+		{[]ansi.Code{"a,b,c,d", ansi.CTRL_A, ansi.CTRL_RIGHT_BRACKET, ",", "X"}, "aX,b,c,d"},
+		{[]ansi.Code{"a,b,c,d", ansi.CTRL_A, ansi.META_2, ansi.CTRL_RIGHT_BRACKET, ",", "X"}, "a,bX,c,d"},
+		{[]ansi.Code{"a,b,c,d", ansi.META_CTRL_RIGHT_BRACKET, ",", "X"}, "a,b,cX,d"},
+		{[]ansi.Code{"a,b,c,d", ansi.META_MINUS, ansi.META_3, ansi.CTRL_RIGHT_BRACKET, ",", "X"}, "aX,b,c,d"},
+		{[]ansi.Code{"日本,日本", ansi.CTRL_A, ansi.CTRL_RIGHT_BRACKET, "日", "X"}, "日本,X日本"},
+	} {
+		s, term := scan(t, uniline.Config{}, append(tc.keys, ansi.CARRIAGE_RETURN)...)
+		if s.Text() != tc.want || term.Bells() != 0 {
+			t.Errorf("%q: got %q with %d bells, want %q", tc.keys, s.Text(), term.Bells(), tc.want)
+		}
+	}
+
+	// the cursor does not move if the character is not found
+	s, term := scan(t, uniline.Config{}, "a,b,c,d", ansi.CTRL_A, ansi.META_9, ansi.CTRL_RIGHT_BRACKET, ",", "X", ansi.CARRIAGE_RETURN)
+	if s.Text() != "Xa,b,c,d" || term.Bells() != 1 {
+		t.Errorf("got %q with %d bells", s.Text(), term.Bells())
+	}
+}
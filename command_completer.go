@@ -0,0 +1,156 @@
+package uniline
+
+import (
+	"flag"
+	"sort"
+	"strings"
+)
+
+// Command is a node of a tree of commands, completing the names of its subcommands and the flags defined in its FlagSet,
+// described by their usage in the completion menu.
+// The root of the tree is the Completer: its own name is not part of the line, which starts with a subcommand.
+//
+// For instance, with the following tree, "re" is completed to "remote", and "remote add -" to "remote add --fetch" and "remote add --tags":
+//
+//	remote := flag.NewFlagSet("remote", flag.ContinueOnError)
+//	add := flag.NewFlagSet("add", flag.ContinueOnError)
+//	add.Bool("fetch", false, "fetch the remote")
+//	add.String("tags", "all", "tags to fetch")
+//	root := &uniline.Command{Subcommands: []*uniline.Command{
+//		{Name: "remote", Flags: remote, Subcommands: []*uniline.Command{
+//			{Name: "add", Flags: add, FlagValues: map[string]func(string) []string{
+//				"tags": func(string) []string { return []string{"all", "none"} },
+//			}},
+//		}},
+//	}}
+type Command struct {
+	// Name is the name of the subcommand, as typed on the line.
+	Name string
+	// Usage describes the subcommand in the completion menu.
+	Usage string
+	// Flags are the flags of the command, if any.
+	Flags *flag.FlagSet
+	// FlagValues complete the values of flags, by flag name. Each function is passed the value typed so far,
+	// and returns the possible values, of which only the ones starting with the typed value are completed.
+	FlagValues map[string]func(value string) []string
+	// Args, if not nil, completes the positional arguments of the command, e.g. with a PathCompleter.
+	Args Completer
+	// Subcommands are the commands that can follow the command, before any positional argument.
+	Subcommands []*Command
+}
+
+// boolFlag is implemented by the flag.Value of the flags that do not take a value, as in package flag.
+type boolFlag interface {
+	IsBoolFlag() bool
+}
+
+// Complete implements Completer.
+func (cmd *Command) Complete(line string, pos int) (start int, candidates []string) {
+	start, described := cmd.CompleteWithDescriptions(line, pos)
+	for _, c := range described {
+		candidates = append(candidates, c.Text)
+	}
+	return start, candidates
+}
+
+// CompleteWithDescriptions implements DescribingCompleter.
+func (cmd *Command) CompleteWithDescriptions(line string, pos int) (start int, candidates []Candidate) {
+	args, start, quote := parseArgs(line[:pos])
+	current := args[len(args)-1]
+
+	var value string // name of the flag expecting a value
+	positional, onlyPositional := false, false
+	for _, arg := range args[:len(args)-1] {
+		switch {
+		case len(value) > 0:
+			value = ""
+		case arg == "--" && !onlyPositional:
+			onlyPositional = true
+		case len(arg) > 1 && arg[0] == '-' && !onlyPositional:
+			name := strings.TrimLeft(arg, "-")
+			if strings.IndexByte(name, '=') < 0 && cmd.Flags != nil {
+				if f := cmd.Flags.Lookup(name); f != nil {
+					if b, ok := f.Value.(boolFlag); !ok || !b.IsBoolFlag() {
+						value = name
+					}
+				}
+			}
+		case !positional && cmd.subcommand(arg) != nil:
+			cmd = cmd.subcommand(arg)
+		default:
+			positional = true
+		}
+	}
+
+	switch {
+	case len(value) > 0:
+		candidates = cmd.flagValues(value, current, "")
+	case len(current) > 0 && current[0] == '-' && !onlyPositional:
+		dashes := "--"
+		if len(current) > 1 && current[1] != '-' {
+			dashes = "-"
+		}
+		name := strings.TrimLeft(current, "-")
+		if i := strings.IndexByte(name, '='); i >= 0 {
+			candidates = cmd.flagValues(name[:i], name[i+1:], dashes+name[:i+1])
+			break
+		}
+		if cmd.Flags != nil {
+			cmd.Flags.VisitAll(func(f *flag.Flag) {
+				if strings.HasPrefix(f.Name, name) {
+					candidates = append(candidates, Candidate{dashes + f.Name, f.Usage})
+				}
+			})
+		}
+	default:
+		if !positional {
+			for _, sub := range cmd.Subcommands {
+				if strings.HasPrefix(sub.Name, current) {
+					candidates = append(candidates, Candidate{sub.Name, sub.Usage})
+				}
+			}
+		}
+		if len(candidates) == 0 && cmd.Args != nil {
+			if dc, ok := cmd.Args.(DescribingCompleter); ok {
+				return dc.CompleteWithDescriptions(line, pos)
+			}
+			start, texts := cmd.Args.Complete(line, pos)
+			for _, t := range texts {
+				candidates = append(candidates, Candidate{Text: t})
+			}
+			return start, candidates
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Text < candidates[j].Text
+	})
+	for i := range candidates {
+		candidates[i].Text = QuoteArgument(candidates[i].Text, quote)
+	}
+	return start, candidates
+}
+
+func (cmd *Command) subcommand(name string) *Command {
+	for _, sub := range cmd.Subcommands {
+		if sub.Name == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+// flagValues returns the values of the flag name starting with typed, prefixed with prefix.
+func (cmd *Command) flagValues(name, typed, prefix string) []Candidate {
+	values, ok := cmd.FlagValues[name]
+	if !ok {
+		return nil
+	}
+	var candidates []Candidate
+	for _, v := range values(typed) {
+		if strings.HasPrefix(v, typed) {
+			candidates = append(candidates, Candidate{Text: prefix + v})
+		}
+	}
+	return candidates
+}
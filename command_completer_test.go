@@ -0,0 +1,62 @@
+package uniline_test
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+
+	"github.com/tiborvass/uniline"
+	"github.com/tiborvass/uniline/ansi"
+)
+
+func remoteCommand() *uniline.Command {
+	remote := flag.NewFlagSet("remote", flag.ContinueOnError)
+	remote.Bool("v", false, "verbose")
+	add := flag.NewFlagSet("add", flag.ContinueOnError)
+	add.Bool("fetch", false, "fetch the remote")
+	add.String("tags", "all", "tags to fetch")
+	return &uniline.Command{Subcommands: []*uniline.Command{
+		{Name: "remote", Flags: remote, Subcommands: []*uniline.Command{
+			{Name: "add", Flags: add, FlagValues: map[string]func(string) []string{
+				"tags": func(string) []string { return []string{"all", "none", "a b"} },
+			}},
+			{Name: "remove"},
+		}},
+		{Name: "reset"},
+	}}
+}
+
+func TestCommandComplete(t *testing.T) {
+	root := remoteCommand()
+	for _, tc := range []struct {
+		line  string
+		start int
+		want  []string
+	}{
+		{"re", 0, []string{"remote", "reset"}},
+		{"remote -v re", 10, []string{"remove"}},
+		{"remote add -", 11, []string{"--fetch", "--tags"}},
+		{"remote add -t", 11, []string{"-tags"}},
+		{"remote add --tags a", 18, []string{`a\ b`, "all"}},
+		{"remote add --tags=n", 11, []string{"--tags=none"}},
+		{"remote add --fetch x", 19, nil},
+		{"remote add -- -", 14, nil},
+	} {
+		start, candidates := root.Complete(tc.line, len(tc.line))
+		if start != tc.start || !reflect.DeepEqual(candidates, tc.want) {
+			t.Errorf("%q: got %d %q, want %d %q", tc.line, start, candidates, tc.start, tc.want)
+		}
+	}
+}
+
+func TestCommandCompleteWithDescriptions(t *testing.T) {
+	_, candidates := remoteCommand().CompleteWithDescriptions("remote add --", 13)
+	want := []uniline.Candidate{{Text: "--fetch", Description: "fetch the remote"}, {Text: "--tags", Description: "tags to fetch"}}
+	if !reflect.DeepEqual(candidates, want) {
+		t.Fatalf("got %q", candidates)
+	}
+	s, _ := scan(t, uniline.Config{Completer: remoteCommand()}, "remote ad", ansi.TAB, " --f", ansi.TAB, ansi.CARRIAGE_RETURN)
+	if s.Text() != "remote add --fetch" {
+		t.Fatalf("got %q", s.Text())
+	}
+}
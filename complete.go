@@ -0,0 +1,178 @@
+package uniline
+
+import "strings"
+
+// Completer provides the candidates for tab completion.
+type Completer interface {
+	// Complete is called with the current line and the cursor position (in bytes).
+	// It returns the candidates that can replace line[start:pos].
+	Complete(line string, pos int) (start int, candidates []string)
+}
+
+// CompleterFunc is an adapter allowing to use an ordinary function as a Completer.
+type CompleterFunc func(line string, pos int) (start int, candidates []string)
+
+// Complete calls f(line, pos).
+func (f CompleterFunc) Complete(line string, pos int) (start int, candidates []string) {
+	return f(line, pos)
+}
+
+// ArgumentCompleterFunc is an adapter allowing to use an ordinary function as a Completer of the shell-like argument at the cursor.
+// The function is passed the unquoted argument, and its candidates are quoted like the argument (c.f. ArgumentAt).
+type ArgumentCompleterFunc func(arg string) (candidates []string)
+
+// Complete calls f with the argument at pos, and quotes the candidates it returns.
+func (f ArgumentCompleterFunc) Complete(line string, pos int) (start int, candidates []string) {
+	start, arg, quote := ArgumentAt(line, pos)
+	for _, c := range f(arg) {
+		candidates = append(candidates, QuoteArgument(c, quote))
+	}
+	return start, candidates
+}
+
+// Candidate is a completion candidate along with its description.
+type Candidate struct {
+	Text        string
+	Description string
+}
+
+// DescribingCompleter is a Completer able to describe its candidates, the descriptions being shown in the completion menu.
+type DescribingCompleter interface {
+	Completer
+	// CompleteWithDescriptions is Complete, returning the candidates along with their descriptions.
+	CompleteWithDescriptions(line string, pos int) (start int, candidates []Candidate)
+}
+
+// Complete replaces the text before the cursor with the only candidate provided by the Completer,
+// or with the longest prefix common to all the candidates, which are then listed in the completion menu.
+//
+// The menu lays out the candidates in columns below the edit line. Tab and Shift-Tab, or the arrow keys, select a candidate,
+// previewing it in the line. Enter keeps the selected candidate, Ctrl-G restores the line, and any other key keeps the
+// selected candidate before being handled as usual.
+func (core *Core) Complete() {
+	if core.completer == nil {
+		core.Bell()
+		return
+	}
+	start, candidates := core.candidates()
+	if len(candidates) == 0 {
+		core.Bell()
+		return
+	}
+	prefix := candidates[0].Text
+	for _, c := range candidates[1:] {
+		prefix = commonPrefix(prefix, c.Text)
+	}
+	startPos := core.buf.PositionAt(start)
+	typed := core.buf.Slice(startPos, core.pos).Clone()
+	if len(candidates) == 1 {
+		if prefix == typed.String() {
+			core.Bell()
+			return
+		}
+		core.replace(startPos, core.pos, textFromString(prefix))
+		return
+	}
+	// the typed text is only ever extended: candidates differing from it in case, for instance, must not replace it
+	if strings.HasPrefix(prefix, typed.String()) && len(prefix) > len(typed.bytes) {
+		core.replace(startPos, core.pos, textFromString(prefix))
+	}
+	core.completionMenu(startPos, typed, candidates)
+}
+
+// candidates returns the candidates of the Completer, with their descriptions if it is a DescribingCompleter.
+func (core *Core) candidates() (start int, candidates []Candidate) {
+	if dc, ok := core.completer.(DescribingCompleter); ok {
+		return dc.CompleteWithDescriptions(core.buf.String(), core.pos.bytes)
+	}
+	start, texts := core.completer.Complete(core.buf.String(), core.pos.bytes)
+	for _, t := range texts {
+		candidates = append(candidates, Candidate{Text: t})
+	}
+	return start, candidates
+}
+
+// commonPrefix returns the longest prefix of a and b, not splitting runes.
+func commonPrefix(a, b string) string {
+	for i, r := range a {
+		if !strings.HasPrefix(b[i:], string(r)) {
+			return a[:i]
+		}
+	}
+	return a
+}
+
+// parseArgs splits s into shell-like arguments, the last one being the (possibly empty) argument at the end of s.
+// It returns the unquoted arguments, where the last one starts in s, and the quote it is still within (0 if none).
+func parseArgs(s string) (args []string, start int, quote byte) {
+	var b strings.Builder
+	escaped, inArg := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+			continue
+		case quote != 0 && c == quote:
+			quote = 0
+			continue
+		case quote != 0:
+		case c == '\'' || c == '"':
+			quote = c
+			inArg = true
+			continue
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, b.String())
+				b.Reset()
+				inArg = false
+			}
+			start = i + 1
+			continue
+		}
+		b.WriteByte(c)
+		inArg = true
+	}
+	return append(args, b.String()), start, quote
+}
+
+// ArgumentAt parses line up to pos (in bytes) as shell-like arguments, delimited by spaces and quoted with quotes or backslashes.
+// It returns where the argument the cursor is in starts in line, its unquoted value, and the quote it is still within (0 if none),
+// so that completers can work on the actual argument and quote their candidates like it, c.f. QuoteArgument.
+func ArgumentAt(line string, pos int) (start int, arg string, quote byte) {
+	args, start, quote := parseArgs(line[:pos])
+	return start, args[len(args)-1], quote
+}
+
+// shellSpecial are the characters to escape in an unquoted shell argument.
+const shellSpecial = " \t\n\\'\"$`&|;<>()*?[]{}#!~"
+
+// QuoteArgument quotes arg as a shell-like argument: within quote if it is not 0, or with backslashes otherwise.
+// The closing quote is omitted, so that the argument can still be completed.
+func QuoteArgument(arg string, quote byte) string {
+	var b strings.Builder
+	switch quote {
+	case '\'':
+		b.WriteByte('\'')
+		b.WriteString(strings.Replace(arg, "'", `'\''`, -1))
+	case '"':
+		b.WriteByte('"')
+		for i := 0; i < len(arg); i++ {
+			if strings.IndexByte("\"\\$`", arg[i]) >= 0 {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(arg[i])
+		}
+	default:
+		for i := 0; i < len(arg); i++ {
+			if strings.IndexByte(shellSpecial, arg[i]) >= 0 {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(arg[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,60 @@
+package uniline_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tiborvass/uniline"
+	"github.com/tiborvass/uniline/ansi"
+)
+
+// words completes the word before the cursor with words, ignoring case if fold is true.
+func words(fold bool, words ...string) uniline.Completer {
+	return uniline.CompleterFunc(func(line string, pos int) (int, []string) {
+		start := strings.LastIndex(line[:pos], " ") + 1
+		var candidates []string
+		for _, w := range words {
+			if strings.HasPrefix(w, line[start:pos]) || fold && strings.HasPrefix(strings.ToLower(w), strings.ToLower(line[start:pos])) {
+				candidates = append(candidates, w)
+			}
+		}
+		return start, candidates
+	})
+}
+
+func TestComplete(t *testing.T) {
+	cfg := uniline.Config{Completer: words(false, "hello", "help", "world")}
+	for _, tc := range []struct {
+		keys  []ansi.Code
+		want  string
+		bells int
+	}{
+		{[]ansi.Code{"say wo", ansi.TAB}, "say world", 0},
+		{[]ansi.Code{"he", ansi.TAB, "p"}, "help", 0},
+		{[]ansi.Code{"x", ansi.TAB}, "x", 1},
+		{[]ansi.Code{"world", ansi.TAB}, "world", 1},
+	} {
+		s, term := scan(t, cfg, append(tc.keys, ansi.CARRIAGE_RETURN)...)
+		if s.Text() != tc.want || term.Bells() != tc.bells {
+			t.Errorf("%q: got %q with %d bells, want %q with %d", tc.keys, s.Text(), term.Bells(), tc.want, tc.bells)
+		}
+	}
+}
+
+func TestCompleteKeepsTypedText(t *testing.T) {
+	// the common prefix of the candidates, "F", does not extend the typed text
+	s, term := scan(t, uniline.Config{Completer: words(true, "Foo", "FOX")}, "fo", ansi.TAB)
+	if s.Text() != "fo" {
+		t.Fatalf("got %q, want %q", s.Text(), "fo")
+	}
+	if len(term.screen) < 2 || !strings.Contains(term.screen[1], "FOX") || !strings.Contains(term.screen[1], "Foo") {
+		t.Fatalf("candidates not listed: %q", term.screen)
+	}
+}
+
+func TestCompleteWithoutCompleter(t *testing.T) {
+	s, term := scan(t, uniline.Config{}, "a", ansi.TAB, ansi.CARRIAGE_RETURN)
+	if s.Text() != "a" || term.Bells() != 1 {
+		t.Fatalf("got %q with %d bells", s.Text(), term.Bells())
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"unicode"
 
 	"github.com/tiborvass/uniline/ansi"
@@ -20,10 +21,30 @@ type Core struct {
 	clipboard clipboard
 	pos       position
 	cols      int // number of columns, aka window width
+	rows      int // number of rows, aka window height
+	below     int // number of rows drawn below the edit line
 	buf       text
 	err       error // the error that will be returned in Err()
 	dumb      bool
-	fd        *uintptr
+	term      Terminal
+	restore   func() // restores the terminal from raw mode
+
+	km         Keymap
+	completer  Completer
+	isWordChar func(r rune) bool
+	wordStyle  WordStyle
+	bell       BellStyle
+
+	historyExpansion bool
+
+	// commands setting command can tell whether they follow themselves, c.f. dispatch
+	command     command
+	lastCommand command
+	yank        yankState
+	arg         numericArg
+	macro       macroState
+	mark        markState
+	key         ansi.Code // the key being dispatched
 
 	// Whether to stop current line's scanning
 	// This is used for internal scanning.
@@ -32,22 +53,120 @@ type Core struct {
 }
 
 type history struct {
-	saved []string
-	tmp   []string
+	historyPolicy
+	saved History
+	edits map[int]string // lines of saved, as edited during the current Scan; index saved.Len() being the current line
 	index int
+
+	// whether the next Scan starts with the entry at index next, c.f. OperateAndGetNext
+	hasNext bool
+	next    int
 }
 
+// command identifies the commands whose behavior depends on the previous command.
+type command int
+
+const (
+	cmdOther command = iota
+	cmdYankArg
+	cmdArgument // the numeric argument is kept for the next command, c.f. Count
+)
+
 type clipboard struct {
 	text    text
 	partial bool
 }
 
+// readKey reads the next key: either a rune, or a complete sequence of the Keymap.
+// The keys of a macro being replayed are read first, and the keys read are recorded if a macro is being recorded.
+// It returns false if there is nothing left to read.
+func (core *Core) readKey() (key ansi.Code, ok bool) {
+	return core.nextKey(core.km)
+}
+
+// readLiteral is readKey, reading a single rune regardless of the Keymap, c.f. QuotedInsert.
+func (core *Core) readLiteral() (key ansi.Code, ok bool) {
+	return core.nextKey(nil)
+}
+
+func (core *Core) nextKey(km Keymap) (key ansi.Code, ok bool) {
+	if len(core.macro.pending) > 0 {
+		key, core.macro.pending = core.macro.pending[0], core.macro.pending[1:]
+	} else if key, ok = core.readInput(km); !ok {
+		return "", false
+	}
+	if core.macro.recording {
+		core.macro.keys = append(core.macro.keys, key)
+	}
+	return key, true
+}
+
+// readInput reads the next key from the input, i.e. a rune or a complete sequence of km.
+func (core *Core) readInput(km Keymap) (key ansi.Code, ok bool) {
+	var p []byte
+	for core.scanner.Scan() {
+		p = append(p, core.scanner.Bytes()...)
+		if scanFun, ok := km[ansi.Code(p)]; ok && scanFun == nil {
+			// beginning of a longer sequence
+			continue
+		}
+		return ansi.Code(p), true
+	}
+	return "", false
+}
+
+// dispatch inserts key if it is printable, otherwise it calls the function key is mapped to in the Keymap.
+func (core *Core) dispatch(key ansi.Code) {
+	// rows drawn below the line, such as error messages, only last until the next key
+	core.clearBelow()
+	core.lastCommand, core.command = core.command, cmdOther
+	core.key = key
+	line := core.buf.String()
+	defer func() {
+		if core.command != cmdArgument {
+			core.arg = numericArg{}
+		}
+		// like in Emacs, editing the line deactivates the region
+		if core.mark.active && core.buf.String() != line {
+			core.mark.active = false
+			core.Refresh()
+		}
+	}()
+	if core.lastCommand == cmdArgument && core.argDigit(key) {
+		return
+	}
+	// if printable, then it's not a command
+	if r, ok := printable(key); ok {
+		for n := abs(core.Count()); n > 0; n-- {
+			core.Insert(charFromRune(r))
+		}
+		return
+	}
+	if scanFun := core.km[key]; scanFun != nil {
+		scanFun(core)
+	}
+	// handle special case for Clipboard
+	if key != ansi.CTRL_W && key != ansi.CTRL_U && key != ansi.CTRL_K {
+		// thus consider the Clipboard as complete and stop gluing Clipboard parts together
+		core.clipboard.partial = false
+	}
+}
+
+// printable returns the rune of key, if key is a single printable rune.
+func printable(key ansi.Code) (r rune, ok bool) {
+	runes := []rune(string(key))
+	if len(runes) != 1 || !unicode.IsPrint(runes[0]) {
+		return 0, false
+	}
+	return runes[0], true
+}
+
 func (core *Core) Insert(c char) {
 	if core.buf.colLen == core.pos.columns {
 		core.buf = core.buf.AppendChar(c)
 		core.pos = core.pos.Add(c)
 		if core.prompt.colLen+core.buf.colLen < core.cols { // TODO: handle multiline
-			mustWrite(core.output.Write(c.p))
+			mustWrite(core.output.Write(c.view()))
 		} else {
 			core.Refresh()
 		}
@@ -58,10 +177,26 @@ func (core *Core) Insert(c char) {
 	}
 }
 
+// QuotedInsert reads the next key and inserts it literally, even if it is a control character such as Tab,
+// which is then drawn in caret notation (e.g. ^I), or as its hexadecimal code (e.g. <85>) for the C1 controls.
+func (core *Core) QuotedInsert() {
+	key, ok := core.readLiteral()
+	if !ok {
+		return
+	}
+	for n := abs(core.Count()); n > 0; n-- {
+		for _, r := range string(key) {
+			core.Insert(charFromRune(r))
+		}
+	}
+}
+
 func (core *Core) Enter() {
-	// removing most recent element of History
+	if core.historyExpansion && !core.expandHistory() {
+		return
+	}
+	// the current line is not part of History
 	// if user actually wants to add it, he can call Scanner.AddToHistory(line)
-	core.history.tmp = core.history.tmp[:len(core.history.tmp)-1]
 
 	// Note: Design decision (differs from the readline in bash)
 	//
@@ -77,12 +212,12 @@ func (core *Core) Enter() {
 	//
 	// At the end, History looks like ["foo", "bar", "foo42"] losing "bar2".
 	// This is differing from bash where History would look like ["foo", "bar2", "foo42"] losing "bar".
-	copy(core.history.tmp, core.history.saved)
+	core.history.edits = nil
 	core.stop = true
 }
 
 func (core *Core) Interrupt() {
-	core.history.tmp = core.history.tmp[:len(core.history.tmp)-1]
+	core.history.edits = nil
 	panic(os.Interrupt)
 }
 
@@ -97,20 +232,40 @@ func (core *Core) DeleteOrEOF() {
 }
 
 func (core *Core) Backspace() {
+	if n := core.Count(); n < 0 {
+		core.delete(-n)
+	} else {
+		core.backspace(n)
+	}
+}
+
+func (core *Core) Delete() {
+	if n := core.Count(); n < 0 {
+		core.backspace(-n)
+	} else {
+		core.delete(n)
+	}
+}
+
+func (core *Core) backspace(n int) {
 	if core.pos.runes > 0 && len(core.buf.chars) > 0 {
-		c := core.buf.chars[core.pos.runes-1]
-		pos2 := core.pos.Subtract(c)
-		core.buf = core.buf.RemoveCharAt(pos2)
-		core.pos = pos2
+		for ; n > 0 && core.pos.runes > 0; n-- {
+			c := core.buf.chars[core.pos.runes-1]
+			pos2 := core.pos.Subtract(c)
+			core.buf = core.buf.RemoveCharAt(pos2)
+			core.pos = pos2
+		}
 		core.Refresh()
 	} else {
 		core.Bell()
 	}
 }
 
-func (core *Core) Delete() {
+func (core *Core) delete(n int) {
 	if len(core.buf.chars) > 0 && core.pos.runes < len(core.buf.chars) {
-		core.buf = core.buf.RemoveCharAt(core.pos)
+		for ; n > 0 && core.pos.runes < len(core.buf.chars); n-- {
+			core.buf = core.buf.RemoveCharAt(core.pos)
+		}
 		core.Refresh()
 	} else {
 		core.Bell()
@@ -118,17 +273,37 @@ func (core *Core) Delete() {
 }
 
 func (core *Core) MoveLeft() {
+	if n := core.Count(); n < 0 {
+		core.moveRight(-n)
+	} else {
+		core.moveLeft(n)
+	}
+}
+
+func (core *Core) MoveRight() {
+	if n := core.Count(); n < 0 {
+		core.moveLeft(-n)
+	} else {
+		core.moveRight(n)
+	}
+}
+
+func (core *Core) moveLeft(n int) {
 	if core.pos.runes > 0 {
-		core.pos = core.pos.Subtract(core.buf.chars[core.pos.runes-1])
+		for ; n > 0 && core.pos.runes > 0; n-- {
+			core.pos = core.pos.Subtract(core.buf.chars[core.pos.runes-1])
+		}
 		core.Refresh()
 	} else {
 		core.Bell()
 	}
 }
 
-func (core *Core) MoveRight() {
+func (core *Core) moveRight(n int) {
 	if core.pos.runes < len(core.buf.chars) {
-		core.pos = core.pos.Add(core.buf.chars[core.pos.runes])
+		for ; n > 0 && core.pos.runes < len(core.buf.chars); n-- {
+			core.pos = core.pos.Add(core.buf.chars[core.pos.runes])
+		}
 		core.Refresh()
 	} else {
 		core.Bell()
@@ -136,37 +311,31 @@ func (core *Core) MoveRight() {
 }
 
 func (core *Core) MoveWordLeft() {
+	if n := core.Count(); n < 0 {
+		core.moveWordRight(-n)
+	} else {
+		core.moveWordLeft(n)
+	}
+}
+
+func (core *Core) MoveWordRight() {
+	if n := core.Count(); n < 0 {
+		core.moveWordLeft(-n)
+	} else {
+		core.moveWordRight(n)
+	}
+}
+
+func (core *Core) moveWordLeft(n int) {
 	if core.pos.runes > 0 {
-		var nonSpaceEncountered bool
-		for pos := core.pos.runes - 1; pos >= 0; pos-- {
-			c := core.buf.chars[pos]
-			if unicode.IsSpace(c.r) {
-				if nonSpaceEncountered {
-					break
-				}
-			} else if !nonSpaceEncountered {
-				nonSpaceEncountered = true
-			}
-			core.pos = core.pos.Subtract(c)
-		}
+		core.pos = core.buf.RunePosition(backwardWords(core.words(), core.pos.runes, n))
 		core.Refresh()
 	}
 }
 
-func (core *Core) MoveWordRight() {
+func (core *Core) moveWordRight(n int) {
 	if core.pos.runes < len(core.buf.chars) {
-		var nonSpaceEncountered bool
-		for pos := core.pos.runes; pos < len(core.buf.chars); pos++ {
-			c := core.buf.chars[pos]
-			if unicode.IsSpace(c.r) {
-				if nonSpaceEncountered {
-					break
-				}
-			} else if !nonSpaceEncountered {
-				nonSpaceEncountered = true
-			}
-			core.pos = core.pos.Add(c)
-		}
+		core.pos = core.buf.RunePosition(forwardWords(core.words(), core.pos.runes, n))
 		core.Refresh()
 	}
 }
@@ -182,27 +351,95 @@ func (core *Core) MoveEnd() {
 }
 
 func (core *Core) HistoryBack() {
-	if core.history.index > 0 {
-		core.history.tmp[core.history.index] = core.buf.String()
-		core.history.index--
-		core.buf = textFromString(core.history.tmp[core.history.index])
-		core.pos = position{len(core.buf.chars), len(core.buf.bytes), core.buf.colLen}
-		core.Refresh()
-	} else {
+	core.historyMove(-core.Count())
+}
+
+func (core *Core) HistoryForward() {
+	core.historyMove(core.Count())
+}
+
+// historyMove moves n entries forward through history, or backward if n is negative, stopping at either end.
+func (core *Core) historyMove(n int) {
+	i := core.history.index + n
+	switch {
+	case i < 0:
+		i = 0
+	case i > core.history.saved.Len():
+		i = core.history.saved.Len()
+	}
+	if i == core.history.index {
 		core.Bell()
+		return
 	}
+	core.historyJump(i)
+	core.Refresh()
 }
 
-func (core *Core) HistoryForward() {
-	if core.history.index < len(core.history.tmp)-1 {
-		core.history.tmp[core.history.index] = core.buf.String()
-		core.history.index++
-		core.buf = textFromString(core.history.tmp[core.history.index])
-		core.pos = position{len(core.buf.chars), len(core.buf.bytes), core.buf.colLen}
-		core.Refresh()
-	} else {
+// OperateAndGetNext submits the line like Enter, and if it comes from history, the next Scan starts with the following entry.
+// This allows to replay a sequence of entries.
+func (core *Core) OperateAndGetNext() {
+	i := core.history.index
+	core.Enter()
+	if core.stop && i < core.history.saved.Len() {
+		core.history.hasNext = true
+		core.history.next = i + 1
+	}
+}
+
+// HistorySearchBackward moves back through history to the previous entry starting with the text before the cursor,
+// keeping the cursor position.
+//
+// It is bound to PageUp by default, but can replace HistoryBack:
+//
+//	km[ansi.UP] = (*Core).HistorySearchBackward
+func (core *Core) HistorySearchBackward() {
+	core.historySearch(Backward)
+}
+
+// HistorySearchForward moves forward through history to the next entry starting with the text before the cursor,
+// keeping the cursor position.
+//
+// It is bound to PageDown by default, but can replace HistoryForward:
+//
+//	km[ansi.DOWN] = (*Core).HistorySearchForward
+func (core *Core) HistorySearchForward() {
+	core.historySearch(Forward)
+}
+
+// historySearch moves through history in direction dir, to the next entry starting with the text before the cursor
+// and differing from the current line.
+func (core *Core) historySearch(dir Direction) {
+	h := &core.history
+	n := h.saved.Len()
+	line := core.buf.String()
+	prefix := core.buf.Slice(position{}, core.pos).String()
+	i := h.index + int(dir)
+	for i = h.saved.Search(prefix, dir, i); i >= 0; i = h.saved.Search(prefix, dir, i+int(dir)) {
+		if l := h.saved.At(i).Line; strings.HasPrefix(l, prefix) && l != line {
+			break
+		}
+	}
+	if i < 0 && dir == Forward && h.index < n && strings.HasPrefix(h.line(n), prefix) {
+		// back to the current line
+		i = n
+	}
+	if i < 0 {
 		core.Bell()
+		return
 	}
+	pos := core.pos
+	core.historyJump(i)
+	core.pos = pos
+	core.Refresh()
+}
+
+// historyJump replaces the line with the ith entry of history, keeping the edits made to the current one.
+// The cursor is moved to the end of the line.
+func (core *Core) historyJump(i int) {
+	core.history.edit(core.history.index, core.buf.String())
+	core.history.index = i
+	core.buf = textFromString(core.history.line(i))
+	core.pos = position{len(core.buf.chars), len(core.buf.bytes), core.buf.colLen}
 }
 
 func (core *Core) CutLineLeft() {
@@ -234,19 +471,16 @@ func (core *Core) CutLineRight() {
 }
 
 func (core *Core) CutPrevWord() {
+	if n := core.Count(); n < 0 {
+		core.cutNextWords(-n)
+	} else {
+		core.cutPrevWords(n)
+	}
+}
+
+func (core *Core) cutPrevWords(n int) {
 	if core.pos.runes > 0 {
-		pos := core.pos
-		var nonSpaceEncountered bool
-		for pos.runes > 0 {
-			if unicode.IsSpace(core.buf.chars[pos.runes-1].r) {
-				if nonSpaceEncountered {
-					break
-				}
-			} else if !nonSpaceEncountered {
-				nonSpaceEncountered = true
-			}
-			pos = pos.Subtract(core.buf.chars[pos.runes-1])
-		}
+		pos := core.buf.RunePosition(backwardWords(core.words(), core.pos.runes, n))
 		if core.clipboard.partial {
 			core.clipboard.text = core.buf.Slice(pos, core.pos).Clone().AppendText(core.clipboard.text)
 		} else {
@@ -261,16 +495,31 @@ func (core *Core) CutPrevWord() {
 	}
 }
 
+func (core *Core) cutNextWords(n int) {
+	if core.pos.runes < len(core.buf.chars) {
+		end := core.buf.RunePosition(forwardWords(core.words(), core.pos.runes, n))
+		if core.clipboard.partial {
+			core.clipboard.text = core.clipboard.text.AppendText(core.buf.Slice(core.pos, end).Clone())
+		} else {
+			core.clipboard.text = core.buf.Slice(core.pos, end).Clone()
+		}
+		core.clipboard.partial = true
+		core.buf = core.buf.Slice(position{}, core.pos).Clone().AppendText(core.buf.Slice(end))
+		core.Refresh()
+	} else {
+		core.Bell()
+	}
+}
+
 func (core *Core) SwapChars() {
 	if core.pos.runes > 0 && len(core.buf.chars) > 1 {
 		pos := core.pos
 		if core.pos.runes == len(core.buf.chars) {
 			pos = pos.Subtract(core.buf.chars[core.pos.runes-1])
 		}
-		core.buf.chars[pos.runes-1], core.buf.chars[pos.runes] = core.buf.chars[pos.runes], core.buf.chars[pos.runes-1]
-		core.buf.bytes[pos.bytes-1], core.buf.bytes[pos.bytes] = core.buf.bytes[pos.bytes], core.buf.bytes[pos.bytes-1]
-		core.pos = pos.Add(core.buf.chars[pos.runes])
-		core.Refresh()
+		a, b := core.buf.chars[pos.runes-1], core.buf.chars[pos.runes]
+		// rebuild the text rather than swapping bytes, since the characters can be encoded differently
+		core.replace(pos.Subtract(a), pos.Add(b), text{}.AppendChar(b).AppendChar(a))
 	} else {
 		core.Bell()
 	}
@@ -288,6 +537,9 @@ func (core *Core) Clear() {
 }
 
 func (core *Core) Bell() {
+	if core.bell == BellNone {
+		return
+	}
 	mustWrite(core.output.Write([]byte(ansi.Bell)))
 }
 
@@ -312,17 +564,23 @@ func (core *Core) Refresh() {
 		pos3 = pos3.Subtract(c)
 		x -= c.colLen
 	}
-	buf = buf.Slice(pos2, pos3)
 
 	mustWrite(fmt.Fprintf(core.output, "%s%s%s%s%s",
 		ansi.CursorToLeftEdge,
 		core.prompt.bytes,
-		buf.bytes,
+		core.highlight(pos2, pos3),
 		ansi.EraseToRight,
 		fmt.Sprintf(ansi.MoveCursorForward, core.prompt.colLen+pos.columns),
 	))
 }
 
+// replace replaces the text between from and to with t, moving the cursor at the end of t.
+func (core *Core) replace(from, to position, t text) {
+	core.buf = core.buf.Slice(position{}, from).Clone().AppendText(t).AppendText(core.buf.Slice(to))
+	core.pos = from.Add(t.chars...)
+	core.Refresh()
+}
+
 func mustWrite(n int, err error) int {
 	if err != nil {
 		panic(err)
@@ -12,7 +12,7 @@ If the provided input source is not a TTY or not an ANSI-compatible TTY, uniline
 
 Features
 	Unicode
-	Optional History (search coming soon)
+	Optional History, with prefix and fuzzy search
 	Fallback for non-TTY or Dumb terminals
 	Single line editing (multiline coming soon)
 
@@ -21,9 +21,18 @@ Supported Keys
 	Right / Ctrl-F
 	Up / Ctrl-P
 	Down / Ctrl-N
+	PageUp / PageDown (history entries starting with the text before the cursor)
+	Ctrl-R (fuzzy history finder)
+	Ctrl-O (submit the line and start the next one with the following history entry)
 
 	Meta-Left
 	Meta-Right
+	Ctrl-] / Meta-Ctrl-] (move to the next or previous occurrence of the next character typed)
+	Meta-^ (history expansion, c.f. Config.HistoryExpansion)
+	Meta-. / Meta-_ (last word of the previous history entries)
+	Meta-U / Meta-L / Meta-C (upper case, lower case or capitalize the word)
+	Meta-T (transpose words)
+	Meta-0..9 / Meta-- (numeric argument of the next key, c.f. Core.Count)
 
 	Backspace / Ctrl-H
 	Delete
@@ -31,12 +40,21 @@ Supported Keys
 	Ctrl-E
 
 	Ctrl-T
-	Ctrl-W
+	Ctrl-W (cuts the region if it is active)
 	Ctrl-K
 	Ctrl-U
 	Ctrl-Y
+	Ctrl-V (insert the next key literally, control characters being shown as ^X)
 	Ctrl-L
 
+	Ctrl-Space (set the mark, activating the region between the mark and the cursor)
+	Ctrl-X Ctrl-X (swap the cursor and the mark)
+	Meta-W (copy the region)
+
+	Tab (completion, with a menu of the candidates; c.f. Config.Completer, PathCompleter and Command)
+	Ctrl-X Ctrl-E (edit the line in $VISUAL or $EDITOR)
+	Ctrl-X ( / Ctrl-X ) / Ctrl-X E (record and replay a keyboard macro)
+
 	Ctrl-C
 	Ctrl-D
 
@@ -67,8 +85,6 @@ Echo Example (available in examples/echo/echo.go):
 
 TODO:
 	Multiline
-	History search
-	Tab completion
 	Catch SIGWINCH when window resizes
 
 */
@@ -0,0 +1,76 @@
+package uniline
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// EditInEditor opens the current line in $VISUAL or $EDITOR (vi by default), and replaces it with the edited content.
+func (core *Core) EditInEditor() {
+	if core.editInEditor() {
+		core.Refresh()
+	}
+}
+
+// EditAndEnter opens the current line in $VISUAL or $EDITOR (vi by default), and submits the edited content.
+func (core *Core) EditAndEnter() {
+	if core.editInEditor() {
+		core.Refresh()
+		core.Enter()
+	}
+}
+
+// editInEditor runs the editor on a temporary file holding the line, with the terminal out of raw mode.
+// It returns false if the line could not be edited.
+func (core *Core) editInEditor() bool {
+	editor := os.Getenv("VISUAL")
+	if len(editor) == 0 {
+		editor = os.Getenv("EDITOR")
+	}
+	if len(editor) == 0 {
+		editor = "vi"
+	}
+
+	f, err := os.CreateTemp("", "uniline-*.txt")
+	if err != nil {
+		core.Bell()
+		return false
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(core.buf.bytes)
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+	if err != nil {
+		core.Bell()
+		return false
+	}
+
+	// like git and bash, the editor is run by the shell, so that it can be quoted, contain spaces or take arguments
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", f.Name())
+	cmd.Stdin = core.input
+	cmd.Stdout = core.output
+	cmd.Stderr = core.output
+
+	core.restore()
+	err = cmd.Run()
+	restore, err2 := core.term.MakeRaw()
+	if err2 != nil {
+		panic(err2)
+	}
+	core.restore = restore
+	if err != nil {
+		core.Bell()
+		return false
+	}
+
+	b, err := os.ReadFile(f.Name())
+	if err != nil {
+		core.Bell()
+		return false
+	}
+	core.buf = textFromString(strings.TrimSuffix(string(b), "\n"))
+	core.pos = position{len(core.buf.chars), len(core.buf.bytes), core.buf.colLen}
+	return true
+}
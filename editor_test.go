@@ -0,0 +1,47 @@
+package uniline_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/tiborvass/uniline"
+	"github.com/tiborvass/uniline/ansi"
+)
+
+// editor writes a shell script appending "!" to the edited line, in a directory whose name contains a space,
+// and returns the quoted path of the script.
+func editor(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh:", err)
+	}
+	dir := filepath.Join(t.TempDir(), "my editor")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	script := filepath.Join(dir, "edit")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nprintf '%s!\\n' \"$(cat \"$1\")\" > \"$1\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return `"` + script + `"`
+}
+
+func TestEditAndEnter(t *testing.T) {
+	t.Setenv("VISUAL", editor(t))
+	s, term := scan(t, uniline.Config{}, "aaa", ansi.CTRL_X_CTRL_E)
+	if s.Text() != "aaa!" || term.Line(0) != "> aaa!" {
+		t.Fatalf("got %q, screen %q", s.Text(), term.Screen())
+	}
+}
+
+func TestEditAndEnterFailure(t *testing.T) {
+	t.Setenv("VISUAL", "exit 1;")
+	t.Setenv("EDITOR", editor(t))
+	// the line is kept as is, and not submitted
+	s, term := scan(t, uniline.Config{}, "aaa", ansi.CTRL_X_CTRL_E, "!", ansi.CARRIAGE_RETURN)
+	if s.Text() != "aaa!" || term.Bells() != 1 {
+		t.Fatalf("got %q with %d bells", s.Text(), term.Bells())
+	}
+}
@@ -0,0 +1,186 @@
+package uniline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/tiborvass/uniline/ansi"
+)
+
+// finderHeight is the maximum number of history entries listed by FuzzyFindHistory.
+const finderHeight = 10
+
+// fuzzyMatch is a history line matching the query of FuzzyFindHistory.
+type fuzzyMatch struct {
+	line      string
+	score     int
+	positions []int // indexes of the matched runes in line
+}
+
+// FuzzyFindHistory lists below the edit line the history entries matching the line as a fuzzy query,
+// i.e. containing its runes in the same order, the best matches first.
+// Typing narrows the list, Up and Down (or Ctrl-P and Ctrl-N) select an entry,
+// Enter or Tab replaces the line with it, while Ctrl-G or Ctrl-C restores the line.
+func (core *Core) FuzzyFindHistory() {
+	buf, pos := core.buf, core.pos
+	query := core.buf.Clone()
+	var selected int
+	for {
+		matches := core.fuzzyFind(query.String())
+		if selected >= len(matches) {
+			selected = len(matches) - 1
+		}
+		if selected < 0 {
+			selected = 0
+		}
+		core.buf = query
+		core.pos = position{len(query.chars), len(query.bytes), query.colLen}
+		core.drawBelow(core.finderRows(matches, selected))
+
+		key, ok := core.readKey()
+		if !ok {
+			core.buf, core.pos = buf, pos
+			core.clearBelow()
+			return
+		}
+		switch key {
+		case ansi.UP, ansi.CTRL_P:
+			selected--
+		case ansi.DOWN, ansi.CTRL_N:
+			selected++
+		case ansi.BACKSPACE, ansi.CTRL_H:
+			if len(query.chars) > 0 {
+				query = query.Slice(position{}, position{}.Add(query.chars[:len(query.chars)-1]...))
+			}
+		case ansi.NEWLINE, ansi.CARRIAGE_RETURN, ansi.TAB:
+			if len(matches) > 0 {
+				buf = textFromString(matches[selected].line)
+				pos = position{len(buf.chars), len(buf.bytes), buf.colLen}
+			}
+			core.buf, core.pos = buf, pos
+			core.clearBelow()
+			return
+		case ansi.CTRL_G, ansi.CTRL_C:
+			core.buf, core.pos = buf, pos
+			core.clearBelow()
+			return
+		default:
+			if r, ok := printable(key); ok {
+				query = query.Clone().AppendChar(charFromRune(r))
+				selected = 0
+			} else {
+				core.Bell()
+			}
+		}
+	}
+}
+
+// fuzzyFind returns the distinct history lines matching query, the best matches first, then the most recent ones.
+func (core *Core) fuzzyFind(query string) []fuzzyMatch {
+	pattern := []rune(query)
+	// smart case: the search is case sensitive only if the query contains upper case runes
+	foldCase := strings.ToLower(query) == query
+	seen := make(map[string]bool)
+	var matches []fuzzyMatch
+	for i := core.history.saved.Len() - 1; i >= 0; i-- {
+		line := core.history.saved.At(i).Line
+		if seen[line] {
+			continue
+		}
+		seen[line] = true
+		if score, positions, ok := fuzzyScore(pattern, []rune(line), foldCase); ok {
+			matches = append(matches, fuzzyMatch{line, score, positions})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	return matches
+}
+
+// fuzzyScore reports whether the runes of pattern appear in s in the same order, and scores the match, fzf-like:
+// matched runes at the beginning of words or following each other score higher, while runes in between score lower.
+// It returns the indexes of the matched runes in s.
+func fuzzyScore(pattern, s []rune, foldCase bool) (score int, positions []int, ok bool) {
+	equal := func(p, r rune) bool {
+		if foldCase {
+			r = unicode.ToLower(r)
+		}
+		return p == r
+	}
+	// find the end of the first occurrence, then the shortest occurrence ending there
+	end, i := -1, 0
+	for j := 0; j < len(s) && i < len(pattern); j++ {
+		if equal(pattern[i], s[j]) {
+			i++
+			end = j
+		}
+	}
+	if i < len(pattern) {
+		return 0, nil, false
+	}
+	if len(pattern) == 0 {
+		return 0, nil, true
+	}
+	positions = make([]int, len(pattern))
+	i = len(pattern) - 1
+	for j := end; i >= 0; j-- {
+		if equal(pattern[i], s[j]) {
+			positions[i] = j
+			i--
+		}
+	}
+
+	for k, j := range positions {
+		score += 16
+		if j == 0 || !unicode.IsLetter(s[j-1]) && !unicode.IsDigit(s[j-1]) {
+			score += 8
+		}
+		if k > 0 {
+			if gap := j - positions[k-1] - 1; gap == 0 {
+				score += 4
+			} else {
+				score -= gap
+			}
+		}
+	}
+	return score, positions, true
+}
+
+// finderRows renders the matches of FuzzyFindHistory, below a row counting them.
+func (core *Core) finderRows(matches []fuzzyMatch, selected int) []string {
+	rows := []string{fmt.Sprintf("  %d/%d", len(matches), core.history.saved.Len())}
+	// the matches are listed below the counting row, in the height of the terminal
+	height := finderHeight
+	if h := core.belowHeight() - 1; h < height {
+		height = h
+	}
+	first := 0
+	if selected >= height {
+		first = selected - height + 1
+	}
+	for i := first; i < len(matches) && i < first+height; i++ {
+		m := matches[i]
+		var b strings.Builder
+		if i == selected {
+			b.WriteString("> ")
+		} else {
+			b.WriteString("  ")
+		}
+		k := 0
+		for j, r := range fitRow(m.line, core.cols-3) {
+			if k < len(m.positions) && m.positions[k] == j {
+				b.WriteString(ansi.Bold)
+				b.WriteRune(r)
+				b.WriteString(ansi.ResetAttributes)
+				k++
+			} else {
+				b.WriteRune(r)
+			}
+		}
+		rows = append(rows, b.String())
+	}
+	return rows
+}
@@ -0,0 +1,65 @@
+package uniline_test
+
+import (
+	"testing"
+
+	"github.com/tiborvass/uniline"
+	"github.com/tiborvass/uniline/ansi"
+	"github.com/tiborvass/uniline/vtest"
+)
+
+func TestFuzzyFindHistory(t *testing.T) {
+	h := new(uniline.MemoryHistory)
+	for _, l := range []string{"git status", "go test ./...", "grep -r foo", "git stash", "ls"} {
+		h.Add(uniline.HistoryEntry{Line: l})
+	}
+	cfg := uniline.Config{History: h}
+
+	_, term := scan(t, cfg, "gst", ansi.CTRL_R, ansi.DOWN)
+	want := []string{"> gst", "  3/5", "  git stash", "> git status", "  go test ./..."}
+	if len(term.screen) != len(want) {
+		t.Fatalf("screen %q, want %q", term.screen, want)
+	}
+	for i := range want {
+		if term.screen[i] != want[i] {
+			t.Fatalf("screen %q, want %q", term.screen, want)
+		}
+	}
+	if term.x != 5 || term.y != 0 {
+		t.Fatalf("cursor at %d,%d", term.x, term.y)
+	}
+
+	s, term := scan(t, cfg, "gst", ansi.CTRL_R, ansi.DOWN, ansi.CARRIAGE_RETURN, "!", ansi.CARRIAGE_RETURN)
+	if s.Text() != "git status!" || term.Line(1) != "" {
+		t.Fatalf("got %q, screen %q", s.Text(), term.Screen())
+	}
+	s, _ = scan(t, cfg, "gst", ansi.CTRL_R, "a", ansi.CTRL_G, ansi.CARRIAGE_RETURN)
+	if s.Text() != "gst" {
+		t.Fatalf("got %q after Ctrl-G", s.Text())
+	}
+}
+
+func TestFuzzyFindHistorySmallTerminal(t *testing.T) {
+	h := new(uniline.MemoryHistory)
+	for _, l := range []string{"git status", "go test ./...", "grep -r foo", "git stash", "ls"} {
+		h.Add(uniline.HistoryEntry{Line: l})
+	}
+	// the edit line is on the last row, the list scrolling the screen up to fit in it
+	term := &snapshotTerminal{Terminal: vtest.New(30, 4)}
+	term.Write([]byte("1\r\n2\r\n3\r\n"))
+	s := uniline.NewScannerWithConfig(uniline.Config{Input: term, History: h})
+	term.Type("gst", ansi.CTRL_R, ansi.DOWN, ansi.DOWN)
+	s.Scan("> ")
+	want := []string{"> gst", "  3/5", "  git status", "> go test ./..."}
+	if len(term.screen) != len(want) {
+		t.Fatalf("screen %q, want %q", term.screen, want)
+	}
+	for i := range want {
+		if term.screen[i] != want[i] {
+			t.Fatalf("screen %q, want %q", term.screen, want)
+		}
+	}
+	if term.x != 5 || term.y != 0 {
+		t.Fatalf("cursor at %d,%d", term.x, term.y)
+	}
+}
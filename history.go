@@ -1,60 +1,265 @@
 package uniline
 
 import (
-	"bufio"
-	"fmt"
 	"os"
+	"strings"
+	"time"
 )
 
+// HistoryControl is a set of flags selecting which lines are left out of history, like bash's HISTCONTROL.
+type HistoryControl int
+
+const (
+	IgnoreSpace HistoryControl = 1 << iota // lines starting with a space are not recorded
+	IgnoreDups                             // lines matching the previous entry are not recorded
+	EraseDups                              // previous entries matching a line are removed before it is recorded
+	IgnoreBoth  = IgnoreSpace | IgnoreDups
+)
+
+// historyPolicy defines which lines are recorded in history, c.f. Config.
+type historyPolicy struct {
+	limit   int // maximum length of saved, 0 meaning no limit
+	control HistoryControl
+	filter  func(line string) bool
+}
+
+// HistoryEntry is a line of history along with its metadata.
+type HistoryEntry struct {
+	Line     string
+	Time     time.Time     // when the line was entered
+	Dir      string        // working directory
+	Duration time.Duration // how long the command took to run, if known
+	Tags     map[string]string
+}
+
+// History stores the entries of history, indexed from the oldest (0) to the most recent (Len()-1).
+// It can be implemented to store history anywhere, c.f. Config.History.
+type History interface {
+	// Len returns the number of entries.
+	Len() int
+	// At returns the ith entry.
+	At(i int) HistoryEntry
+	// Add appends an entry.
+	Add(e HistoryEntry)
+	// Remove removes the ith entry, shifting the following ones.
+	Remove(i int)
+	// Search returns the index of the first entry whose line contains query, starting at index from
+	// and going in direction dir, or -1 if there is none.
+	Search(query string, dir Direction, from int) int
+}
+
+// Direction is the direction in which History is searched.
+type Direction int
+
+const (
+	Backward Direction = -1 // from the most recent entries to the oldest
+	Forward  Direction = 1  // from the oldest entries to the most recent
+)
+
+// MemoryHistory is the default History, keeping the entries in memory.
+// The zero value is an empty history ready to use.
+type MemoryHistory struct {
+	entries []HistoryEntry
+}
+
+func (h *MemoryHistory) Len() int {
+	return len(h.entries)
+}
+
+func (h *MemoryHistory) At(i int) HistoryEntry {
+	return h.entries[i]
+}
+
+func (h *MemoryHistory) Add(e HistoryEntry) {
+	h.entries = append(h.entries, e)
+}
+
+func (h *MemoryHistory) Remove(i int) {
+	h.entries = append(h.entries[:i], h.entries[i+1:]...)
+}
+
+func (h *MemoryHistory) Search(query string, dir Direction, from int) int {
+	for i := from; i >= 0 && i < len(h.entries); i += int(dir) {
+		if strings.Contains(h.entries[i].Line, query) {
+			return i
+		}
+	}
+	return -1
+}
+
 // ClearHistory Clears history.
 func (s *Scanner) ClearHistory() {
-	s.history = history{}
+	s.history.clear()
 }
 
-// AddToHistory adds a string line to history
+// History returns the History of the Scanner, c.f. Config.History.
+func (s *Scanner) History() History {
+	return s.history.saved
+}
+
+// AddToHistory adds a string line to history, unless it is left out by the history settings of the Scanner (c.f. Config).
+// The current time and working directory are recorded along with the line.
 func (s *Scanner) AddToHistory(line string) {
-	s.history.tmp = append(s.history.tmp, line)
-	s.history.saved = append(s.history.saved, line)
+	dir, _ := os.Getwd()
+	s.AddHistoryEntry(HistoryEntry{Line: line, Time: time.Now(), Dir: dir})
+}
+
+// AddHistoryEntry adds an entry to history, unless it is left out by the history settings of the Scanner (c.f. Config).
+// If history is shared (c.f. ShareHistory), the entry is also appended to the shared history file.
+func (s *Scanner) AddHistoryEntry(e HistoryEntry) {
+	if !s.history.add(e) || s.shared == nil {
+		return
+	}
+	if err := s.shared.append(e); err != nil && s.shared.err == nil {
+		s.shared.err = err
+	}
+}
+
+// HistoryEntries returns a copy of the entries in history, from the oldest to the most recent.
+func (s *Scanner) HistoryEntries() []HistoryEntry {
+	entries := make([]HistoryEntry, s.history.saved.Len())
+	for i := range entries {
+		entries[i] = s.history.saved.At(i)
+	}
+	return entries
 }
 
 // SaveHistory saves the current history to a file specified by filename.
+// Entries left out by the history settings of the Scanner are not saved.
+//
+// The file is replaced atomically, so that it is never left partially written.
+// If it is the shared history file (c.f. ShareHistory), the entries added by other processes are merged first.
 func (s *Scanner) SaveHistory(filename string) error {
-	f, err := os.Create(filename)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	for _, line := range s.history.tmp[:len(s.history.tmp)-1] {
-		if _, err := fmt.Fprintln(f, line); err != nil {
+	shared := s.shared != nil && s.shared.name == filename
+	if shared {
+		unlock, err := lockFile(filename)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+		// entries added by other processes would be lost otherwise
+		if err := s.mergeHistory(); err != nil {
 			return err
 		}
 	}
+	m := new(MemoryHistory)
+	h := history{historyPolicy: s.history.historyPolicy, saved: m}
+	for i := 0; i < s.history.saved.Len(); i++ {
+		h.add(s.history.saved.At(i))
+	}
+	if err := writeHistoryAtomic(filename, m.entries); err != nil {
+		return err
+	}
+	if shared {
+		// the file was replaced, mark it as read
+		_, _, err := s.shared.readNew()
+		return err
+	}
 	return nil
 }
 
 // LoadHistory loads history from a file specified by filename.
+// Entries left out by the history settings of the Scanner are not loaded.
+//
+// Besides the format written by SaveHistory, files with one line of history per line, without metadata, can be loaded.
 func (s *Scanner) LoadHistory(filename string) error {
 	f, err := os.Open(filename)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
-	scanner := bufio.NewScanner(f)
-	lines := []string{}
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
-	}
-	if err := scanner.Err(); err != nil {
+	var format historyFormat
+	entries, err := decodeHistory(f, &format)
+	if err != nil {
 		return err
 	}
+	s.history.clear()
+	for _, e := range entries {
+		s.history.add(e)
+	}
+	return nil
+}
+
+// add appends e to history, if the policy accepts it.
+// It returns whether e was added.
+func (h *history) add(e HistoryEntry) bool {
+	n := h.saved.Len()
+	if h.control&IgnoreSpace != 0 && strings.HasPrefix(e.Line, " ") {
+		return false
+	}
+	if h.control&IgnoreDups != 0 && n > 0 && h.saved.At(n-1).Line == e.Line {
+		return false
+	}
+	if h.filter != nil && !h.filter(e.Line) {
+		return false
+	}
+	if h.control&EraseDups != 0 {
+		for i := h.saved.Search(e.Line, Backward, n-1); i >= 0; i = h.saved.Search(e.Line, Backward, i-1) {
+			if h.saved.At(i).Line == e.Line {
+				h.remove(i)
+			}
+		}
+	}
+	h.saved.Add(e)
+	h.evict()
+	return true
+}
 
-	// tmp = saved = loaded History
-	s.history.saved = lines
-	s.history.tmp = make([]string, len(s.history.saved))
-	copy(s.history.tmp, s.history.saved)
+// remove removes the ith entry of history.
+func (h *history) remove(i int) {
+	h.saved.Remove(i)
+	if h.index > i {
+		h.index--
+	}
+	if h.next > i {
+		h.next--
+	}
+	if len(h.edits) == 0 {
+		return
+	}
+	edits := make(map[int]string, len(h.edits))
+	for j, line := range h.edits {
+		if j > i {
+			edits[j-1] = line
+		} else if j < i {
+			edits[j] = line
+		}
+	}
+	h.edits = edits
+}
 
-	// add current line
-	s.history.tmp = append(s.history.tmp, s.buf.String())
-	s.history.index = len(s.history.tmp) - 1
-	return nil
+// evict removes the oldest entries of history exceeding its limit.
+func (h *history) evict() {
+	for h.limit > 0 && h.saved.Len() > h.limit {
+		h.remove(0)
+	}
+}
+
+// clear removes all the entries of history.
+func (h *history) clear() {
+	for n := h.saved.Len(); n > 0; n-- {
+		h.saved.Remove(n - 1)
+	}
+	h.edits = nil
+	h.index = 0
+	h.hasNext = false
+}
+
+// line returns the line of the ith entry of history, as edited during the current Scan.
+func (h *history) line(i int) string {
+	if line, ok := h.edits[i]; ok {
+		return line
+	}
+	if i == h.saved.Len() {
+		return ""
+	}
+	return h.saved.At(i).Line
+}
+
+// edit records the edited line of the ith entry of history.
+func (h *history) edit(i int, line string) {
+	if h.edits == nil {
+		h.edits = make(map[int]string)
+	}
+	h.edits[i] = line
 }
@@ -0,0 +1,277 @@
+package uniline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ExpandHistory replaces the line with its history expansion (c.f. Config.HistoryExpansion).
+// If the expansion fails, the error is shown below the line.
+func (core *Core) ExpandHistory() {
+	core.expandHistory()
+}
+
+// expandHistory is ExpandHistory, returning whether the expansion succeeded.
+func (core *Core) expandHistory() bool {
+	line, err := expandHistory(core.buf.String(), core.history.saved)
+	if err != nil {
+		core.Bell()
+		core.drawBelow([]string{string(fitRow(err.Error(), core.cols-1))})
+		return false
+	}
+	if line != core.buf.String() {
+		core.buf = textFromString(line)
+		core.pos = position{len(core.buf.chars), len(core.buf.bytes), core.buf.colLen}
+		core.Refresh()
+	}
+	return true
+}
+
+// expandHistory performs bash's history expansion of line, with the events of h:
+//
+//	!!        the previous entry
+//	!n        the nth entry, starting from 1
+//	!-n       the nth entry before the current line
+//	!prefix   the most recent entry starting with prefix
+//	!?sub?    the most recent entry containing sub
+//	!#        the current line, up to this point
+//
+// An event can be followed by a word designator, the words being numbered from 0:
+//
+//	:n        the nth word
+//	:^        the first word (:1)
+//	:$        the last word
+//	:n-m      the words from n to m, n or m defaulting to 0 and $-1
+//	:n*       the words from n to the last one
+//	:*        the words from 1 to the last one
+//
+// The colon can be omitted before ^, $, * and -, and !$, !^, !* and !:n stand for !!:$, !!:^, !!:* and !!:n.
+// Finally, ^old^new^ at the beginning of line stands for the previous entry, with old replaced by new.
+//
+// Like in bash, history expansion does not happen inside single quotes, after a backslash,
+// or when ! is followed by a space, a tab, = or (.
+func expandHistory(line string, h History) (string, error) {
+	if strings.HasPrefix(line, "^") {
+		return quickSubstitution(line, h)
+	}
+	var b strings.Builder
+	var single, double bool // whether inside single or double quotes
+	for i := 0; i < len(line); {
+		switch c := line[i]; {
+		case c == '\\' && !single && i+1 < len(line):
+			b.WriteString(line[i : i+2])
+			i += 2
+			continue
+		case c == '\'' && !double:
+			single = !single
+		case c == '"' && !single:
+			double = !double
+		case c == '!' && !single:
+			expansion, n, err := expandEvent(line[i:], h, b.String())
+			if err != nil {
+				return "", err
+			}
+			if n > 0 {
+				b.WriteString(expansion)
+				i += n
+				continue
+			}
+		}
+		b.WriteByte(line[i])
+		i++
+	}
+	return b.String(), nil
+}
+
+// expandEvent expands the event designator, and the word designator if any, at the beginning of s.
+// It returns the expansion along with the length of the designators in s, 0 meaning that s does not start with an event.
+// current is the expansion of the line before s.
+func expandEvent(s string, h History, current string) (expansion string, n int, err error) {
+	if len(s) < 2 || strings.IndexByte(" \t\n=(", s[1]) >= 0 {
+		return "", 0, nil
+	}
+	last := h.Len() - 1
+	i := -1 // index of the event in h
+	j := 1  // length of the event designator
+	switch c := s[1]; {
+	case c == '!':
+		i, j = last, 2
+	case c == '#':
+		expansion, j = current, 2
+	case strings.IndexByte("^$*:", c) >= 0:
+		// word designator of the previous entry
+		i = last
+	case c == '-' || c >= '0' && c <= '9':
+		for j = 2; j < len(s) && s[j] >= '0' && s[j] <= '9'; j++ {
+		}
+		k, err := strconv.Atoi(s[1:j])
+		if err != nil {
+			return "", 0, fmt.Errorf("%s: event not found", s[:j])
+		}
+		if k < 0 {
+			i = h.Len() + k
+		} else {
+			i = k - 1
+		}
+	case c == '?':
+		k := strings.IndexByte(s[2:], '?')
+		sub := s[2:]
+		if k < 0 {
+			j = len(s)
+		} else {
+			sub = s[2 : 2+k]
+			j = 2 + k + 1
+		}
+		if i = h.Search(sub, Backward, last); i < 0 {
+			return "", 0, fmt.Errorf("%s: event not found", s[:j])
+		}
+	default:
+		// the event is a prefix of an entry, up to a space or a word designator, not splitting runes
+		for j = 1; j < len(s); {
+			r, size := utf8.DecodeRuneInString(s[j:])
+			if unicode.IsSpace(r) || strings.ContainsRune(":;&|()<>'\"", r) {
+				break
+			}
+			j += size
+		}
+		prefix := s[1:j]
+		if len(prefix) == 0 {
+			return "", 0, nil
+		}
+		for i = h.Search(prefix, Backward, last); i >= 0; i = h.Search(prefix, Backward, i-1) {
+			if strings.HasPrefix(h.At(i).Line, prefix) {
+				break
+			}
+		}
+	}
+	if s[1] != '#' {
+		if i < 0 || i > last {
+			if j < 2 {
+				j = 2
+			}
+			return "", 0, fmt.Errorf("%s: event not found", s[:j])
+		}
+		expansion = h.At(i).Line
+	}
+
+	if j < len(s) && s[j] == ':' && j+1 < len(s) && strings.IndexByte("0123456789^$*-", s[j+1]) >= 0 {
+		j++
+	} else if j >= len(s) || strings.IndexByte("^$*-", s[j]) < 0 {
+		return expansion, j, nil
+	}
+	words, k, err := selectWords(s[j:], splitHistoryWords(expansion))
+	if err != nil {
+		return "", 0, err
+	}
+	return words, j + k, nil
+}
+
+// selectWords selects the words designated at the beginning of s, and returns them along with the length of the designator.
+func selectWords(s string, words []string) (selected string, n int, err error) {
+	last := len(words) - 1
+	// number parses the word number at s[n:], $ being the last word
+	number := func() (int, bool) {
+		if n < len(s) && s[n] == '$' {
+			n++
+			return last, true
+		}
+		k := n
+		for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+			n++
+		}
+		i, err := strconv.Atoi(s[k:n])
+		return i, err == nil
+	}
+
+	first, end := 1, last
+	switch s[0] {
+	case '^':
+		first, end, n = 1, 1, 1
+	case '*':
+		if last < 1 {
+			return "", 1, nil
+		}
+		n = 1
+	case '-':
+		first, n = 0, 1
+		if end, _ = number(); n == 1 {
+			return "", 0, fmt.Errorf(":%s: bad word specifier", s[:n])
+		}
+	default:
+		first, _ = number()
+		end = first
+		if n < len(s) && s[n] == '*' {
+			n++
+			end = last
+		} else if n < len(s) && s[n] == '-' {
+			n++
+			k := n
+			if end, _ = number(); n == k {
+				// x- abbreviates x-$ without the last word
+				end = last - 1
+			}
+		}
+	}
+	if first < 0 || end > last || first > end {
+		return "", 0, fmt.Errorf(":%s: bad word specifier", s[:n])
+	}
+	return strings.Join(words[first:end+1], " "), n, nil
+}
+
+// quickSubstitution expands ^old^new^, i.e. the previous entry with old replaced by new.
+func quickSubstitution(line string, h History) (string, error) {
+	parts := strings.SplitN(line[1:], "^", 3)
+	if len(parts) < 2 || len(parts[0]) == 0 {
+		return "", fmt.Errorf("%s: substitution failed", line)
+	}
+	if h.Len() == 0 {
+		return "", fmt.Errorf("%s: event not found", line)
+	}
+	prev := h.At(h.Len() - 1).Line
+	if !strings.Contains(prev, parts[0]) {
+		return "", fmt.Errorf("%s: substitution failed", line)
+	}
+	expansion := strings.Replace(prev, parts[0], parts[1], 1)
+	if len(parts) == 3 {
+		expansion += parts[2]
+	}
+	return expansion, nil
+}
+
+// splitHistoryWords splits line into words delimited by spaces, keeping quoted strings and escaped spaces in the words.
+func splitHistoryWords(line string) []string {
+	var words []string
+	var quote rune
+	start := -1
+	escaped := false
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case unicode.IsSpace(r):
+			if start >= 0 {
+				words = append(words, line[start:i])
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, line[start:])
+	}
+	return words
+}
@@ -0,0 +1,58 @@
+package uniline_test
+
+import (
+	"testing"
+
+	"github.com/tiborvass/uniline"
+	"github.com/tiborvass/uniline/ansi"
+)
+
+func TestExpandHistory(t *testing.T) {
+	h := new(uniline.MemoryHistory)
+	for _, l := range []string{"git commit -m 'a b'", "càt file", "ls -l /tmp /var", "echo hello world"} {
+		h.Add(uniline.HistoryEntry{Line: l})
+	}
+	cfg := uniline.Config{History: h}
+	for in, want := range map[string]string{
+		"!!":            "echo hello world",
+		"sudo !!":       "sudo echo hello world",
+		"!$":            "world",
+		"!^":            "hello",
+		"!*":            "hello world",
+		"!-2:2":         "/tmp",
+		"!1:3":          "'a b'",
+		"!ls:1-2":       "-l /tmp",
+		"!ls:1-":        "-l /tmp",
+		"!?commit?:0":   "git",
+		"!3:-1":         "ls -l",
+		"!cà":           "càt file",
+		"!cà:1 x":       "file x",
+		"echo '!!'":     "echo '!!'",
+		"echo \\!!":     "echo \\!!",
+		"a != b":        "a != b",
+		"x !# y":        "x x  y",
+		"^hello^bye^ !": "echo bye world !",
+	} {
+		s, term := scan(t, cfg, ansi.Code(in), ansi.META_CARET, ansi.CARRIAGE_RETURN)
+		if s.Text() != want || term.Bells() != 0 {
+			t.Errorf("%q: got %q with %d bells, want %q", in, s.Text(), term.Bells(), want)
+		}
+	}
+	for _, in := range []string{"!foo", "!9", "!!:9", "^zz^y", "!?nope"} {
+		s, term := scan(t, cfg, ansi.Code(in), ansi.META_CARET, ansi.CARRIAGE_RETURN)
+		if s.Text() != in || term.Bells() != 1 {
+			t.Errorf("%q: got %q with %d bells", in, s.Text(), term.Bells())
+		}
+	}
+}
+
+func TestHistoryExpansionOnEnter(t *testing.T) {
+	h := new(uniline.MemoryHistory)
+	h.Add(uniline.HistoryEntry{Line: "echo hi"})
+	cfg := uniline.Config{History: h, HistoryExpansion: true}
+	// the line is not submitted if the expansion fails
+	s, term := scan(t, cfg, "!x", ansi.CARRIAGE_RETURN, ansi.CTRL_U, "!! there", ansi.CARRIAGE_RETURN)
+	if s.Text() != "echo hi there" {
+		t.Fatalf("got %q, screen %q", s.Text(), term.Screen())
+	}
+}
@@ -0,0 +1,310 @@
+package uniline
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// historyFile is a history file shared with other processes, c.f. ShareHistory.
+type historyFile struct {
+	name     string
+	info     os.FileInfo    // the file when last read, to detect whether it was replaced
+	offset   int64          // how much of the file was read
+	format   historyFormat  // format of the file, as detected when reading it from the beginning
+	unmerged []HistoryEntry // entries appended by other processes, that are not merged yet
+	stale    bool           // whether the file was replaced, so that history has to be read again
+	err      error          // first error encountered when appending to the file
+}
+
+// historyFormat is the version of the format of a history file.
+type historyFormat int
+
+const (
+	formatUnknown historyFormat = iota // nothing was read yet
+	formatPlain                        // one line per entry, without metadata
+	formatV1                           // historyHeader followed by one JSON object per entry
+)
+
+const historyHeader = "#uniline-history 1"
+
+// historyRecord is the JSON representation of a HistoryEntry in a history file.
+// Since newlines are escaped, each entry fits in one line of the file.
+type historyRecord struct {
+	Line     string            `json:"line"`
+	Time     string            `json:"time,omitempty"`
+	Dir      string            `json:"dir,omitempty"`
+	Duration string            `json:"duration,omitempty"`
+	Tags     map[string]string `json:"tags,omitempty"`
+}
+
+// ErrLockUnsupported is returned by ShareHistory on the platforms where files cannot be locked, i.e. other than unix ones.
+var ErrLockUnsupported = errors.New("uniline: files cannot be locked on this platform")
+
+// ShareHistory loads history from the file specified by filename, and then appends to it every entry added to history,
+// like zsh's inc_append_history.
+// The file is locked while being written, so that it can be shared by several processes at the same time.
+// Entries added by the other processes are merged into history when calling MergeHistory.
+//
+// On the platforms where files cannot be locked, history is not shared, and ErrLockUnsupported is returned.
+func (s *Scanner) ShareHistory(filename string) error {
+	s.shared = &historyFile{name: filename}
+	err := s.MergeHistory()
+	if err == ErrLockUnsupported {
+		s.shared = nil
+	}
+	return err
+}
+
+// MergeHistory adds to history the entries appended to the shared history file by other processes (c.f. ShareHistory),
+// like zsh's share_history.
+// If the file was entirely rewritten, history is loaded from it again.
+//
+// MergeHistory also returns the first error encountered while appending entries to the shared history file since the last call.
+func (s *Scanner) MergeHistory() error {
+	if s.shared == nil {
+		return nil
+	}
+	unlock, err := lockFile(s.shared.name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := s.mergeHistory(); err != nil {
+		return err
+	}
+	err, s.shared.err = s.shared.err, nil
+	return err
+}
+
+// mergeHistory is MergeHistory, once the shared history file is locked.
+func (s *Scanner) mergeHistory() error {
+	hf := s.shared
+	if hf.stale {
+		// forces reading the whole file
+		hf.info = nil
+	}
+	entries, replaced, err := hf.readNew()
+	if err != nil {
+		return err
+	}
+	if replaced {
+		s.history.clear()
+		hf.unmerged = nil
+	}
+	for _, e := range append(hf.unmerged, entries...) {
+		s.history.add(e)
+	}
+	hf.unmerged = nil
+	hf.stale = false
+	return nil
+}
+
+// append appends e to the file, after having read the entries appended by other processes.
+func (hf *historyFile) append(e HistoryEntry) error {
+	unlock, err := lockFile(hf.name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, replaced, err := hf.readNew()
+	if err != nil {
+		return err
+	}
+	if replaced {
+		hf.unmerged = nil
+		hf.stale = true
+	} else {
+		hf.unmerged = append(hf.unmerged, entries...)
+	}
+
+	if hf.format == formatPlain {
+		// upgrade the file to the current format, since e may not fit in a plain line
+		if err := hf.upgrade(); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(hf.name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if hf.format == formatUnknown {
+		if _, err := fmt.Fprintln(f, historyHeader); err != nil {
+			return err
+		}
+		hf.format = formatV1
+	}
+	if err := encodeHistoryEntry(f, e); err != nil {
+		return err
+	}
+	return hf.update(f)
+}
+
+// upgrade rewrites the file in the current format.
+func (hf *historyFile) upgrade() error {
+	f, err := os.Open(hf.name)
+	if err != nil {
+		return err
+	}
+	var format historyFormat
+	entries, err := decodeHistory(f, &format)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	if err := writeHistoryAtomic(hf.name, entries); err != nil {
+		return err
+	}
+	// history is read again on the next merge, as if another process had replaced the file
+	hf.unmerged = nil
+	hf.stale = true
+	hf.format = formatV1
+	return nil
+}
+
+// readNew returns the entries appended to the file since it was last read.
+// If the file was replaced, all of its entries are returned, and replaced is true.
+func (hf *historyFile) readNew() (entries []HistoryEntry, replaced bool, err error) {
+	f, err := os.Open(hf.name)
+	if os.IsNotExist(err) {
+		// the file is created again from scratch, with its header, on the next append
+		hf.info = nil
+		hf.offset = 0
+		hf.format = formatUnknown
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+	if hf.info == nil || !os.SameFile(hf.info, info) || info.Size() < hf.offset {
+		replaced = true
+		hf.offset = 0
+		hf.format = formatUnknown
+	}
+	if _, err := f.Seek(hf.offset, io.SeekStart); err != nil {
+		return nil, false, err
+	}
+	entries, err = decodeHistory(f, &hf.format)
+	if err != nil {
+		return nil, false, err
+	}
+	return entries, replaced, hf.update(f)
+}
+
+// update records that the file f was read or written entirely.
+func (hf *historyFile) update(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	hf.info = info
+	hf.offset = info.Size()
+	return nil
+}
+
+// writeHistoryAtomic writes entries to the file specified by filename through a temporary file renamed over it,
+// so that the file is never left partially written.
+func writeHistoryAtomic(filename string, entries []HistoryEntry) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	w := bufio.NewWriter(f)
+	if _, err := fmt.Fprintln(w, historyHeader); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := encodeHistoryEntry(w, e); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filename)
+}
+
+// encodeHistoryEntry writes e as one line of a history file.
+func encodeHistoryEntry(w io.Writer, e HistoryEntry) error {
+	r := historyRecord{Line: e.Line, Dir: e.Dir, Tags: e.Tags}
+	if !e.Time.IsZero() {
+		r.Time = e.Time.Format(time.RFC3339Nano)
+	}
+	if e.Duration != 0 {
+		r.Duration = e.Duration.String()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(r)
+}
+
+// decodeHistory decodes the entries of a history file read from r.
+// If format is unknown, r is expected to read the file from the beginning, and format is detected from the first line.
+func decodeHistory(r io.Reader, format *historyFormat) ([]HistoryEntry, error) {
+	var entries []HistoryEntry
+	scanner := newHistoryScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch *format {
+		case formatUnknown:
+			if !strings.HasPrefix(line, "#uniline-history ") {
+				*format = formatPlain
+				entries = append(entries, HistoryEntry{Line: line})
+			} else if line == historyHeader {
+				*format = formatV1
+			} else {
+				return nil, fmt.Errorf("unsupported history file format %q", line)
+			}
+		case formatPlain:
+			entries = append(entries, HistoryEntry{Line: line})
+		case formatV1:
+			var r historyRecord
+			if err := json.Unmarshal([]byte(line), &r); err != nil {
+				return nil, fmt.Errorf("invalid history entry %q: %v", line, err)
+			}
+			e := HistoryEntry{Line: r.Line, Dir: r.Dir, Tags: r.Tags}
+			if len(r.Time) > 0 {
+				t, err := time.Parse(time.RFC3339Nano, r.Time)
+				if err != nil {
+					return nil, fmt.Errorf("invalid history entry %q: %v", line, err)
+				}
+				e.Time = t
+			}
+			if len(r.Duration) > 0 {
+				d, err := time.ParseDuration(r.Duration)
+				if err != nil {
+					return nil, fmt.Errorf("invalid history entry %q: %v", line, err)
+				}
+				e.Duration = d
+			}
+			entries = append(entries, e)
+		}
+	}
+	return entries, scanner.Err()
+}
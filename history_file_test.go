@@ -0,0 +1,135 @@
+package uniline_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tiborvass/uniline"
+)
+
+// sharing returns n Scanners sharing the history file specified by filename.
+func sharing(t *testing.T, filename string, n int) []*uniline.Scanner {
+	t.Helper()
+	var scanners []*uniline.Scanner
+	for i := 0; i < n; i++ {
+		s := uniline.NewScanner(strings.NewReader(""), nil, nil, nil)
+		if err := s.ShareHistory(filename); err == uniline.ErrLockUnsupported {
+			t.Skip(err)
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		scanners = append(scanners, s)
+	}
+	return scanners
+}
+
+// savedHistory returns the lines of the history of s, as saved by SaveHistory.
+func savedHistory(t *testing.T, s *uniline.Scanner) []string {
+	t.Helper()
+	fn := filepath.Join(t.TempDir(), "saved")
+	if err := s.SaveHistory(fn); err != nil {
+		t.Fatal(err)
+	}
+	return loadHistory(t, fn)
+}
+
+func TestShareHistory(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "history")
+	scanners := sharing(t, fn, 2)
+	a, b := scanners[0], scanners[1]
+	a.AddToHistory("one")
+	b.AddToHistory("two")
+	a.AddToHistory("three")
+	if got := strings.Join(loadHistory(t, fn), "|"); got != "one|two|three" {
+		t.Fatalf("file has %q", got)
+	}
+	if got := strings.Join(savedHistory(t, b), "|"); got != "two" {
+		t.Fatalf("history is %q before merging", got)
+	}
+	if err := b.MergeHistory(); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(savedHistory(t, b), "|"); got != "one|two|three" {
+		t.Fatalf("merged history is %q", got)
+	}
+}
+
+func TestShareHistoryReplacedFile(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "history")
+	scanners := sharing(t, fn, 2)
+	a, b := scanners[0], scanners[1]
+	a.AddToHistory("a1")
+	b.AddToHistory("b1")
+	a.AddToHistory("a2")
+	if err := a.MergeHistory(); err != nil {
+		t.Fatal(err)
+	}
+	// b rewrites the file, which a loads again
+	if err := b.SaveHistory(fn); err != nil {
+		t.Fatal(err)
+	}
+	a.AddToHistory("a3")
+	if err := a.MergeHistory(); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(savedHistory(t, a), "|"); got != "a1|b1|a2|a3" {
+		t.Fatalf("history is %q", got)
+	}
+}
+
+func TestShareHistoryRemovedFile(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "history")
+	s := sharing(t, fn, 1)[0]
+	s.AddToHistory("one")
+	if err := os.Remove(fn); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MergeHistory(); err != nil {
+		t.Fatal(err)
+	}
+	// the file is created again, with its header
+	s.AddToHistory("two")
+	if err := s.MergeHistory(); err != nil {
+		t.Fatal(err)
+	}
+	if got := loadHistory(t, fn); len(got) != 1 || got[0] != "two" {
+		t.Fatalf("file has %q", got)
+	}
+}
+
+func TestShareHistoryUpgrade(t *testing.T) {
+	// a file of plain lines is upgraded, so that the metadata of the entries are kept
+	fn := filepath.Join(t.TempDir(), "history")
+	if err := os.WriteFile(fn, []byte("old1\nold2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s := sharing(t, fn, 1)[0]
+	s.AddHistoryEntry(uniline.HistoryEntry{Line: "multi\nline", Dir: "/tmp", Tags: map[string]string{"k": "v"}, Duration: 1500 * time.Millisecond})
+	if err := s.MergeHistory(); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(loadHistory(t, fn), "|"); got != "old1|old2|multi\nline" {
+		t.Fatalf("file has %q", got)
+	}
+	entries := s.HistoryEntries()
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries", len(entries))
+	}
+	if e := entries[2]; e.Dir != "/tmp" || e.Tags["k"] != "v" || e.Duration != 1500*time.Millisecond {
+		t.Fatalf("got %+v", e)
+	}
+}
+
+func TestAddToHistoryMetadata(t *testing.T) {
+	s := uniline.NewScanner(strings.NewReader(""), nil, nil, nil)
+	before := time.Now()
+	s.AddToHistory("ls")
+	wd, _ := os.Getwd()
+	e := s.HistoryEntries()[0]
+	if e.Time.Before(before.Truncate(time.Second)) || e.Dir != wd {
+		t.Fatalf("got %+v", e)
+	}
+}
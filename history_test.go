@@ -0,0 +1,152 @@
+package uniline_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tiborvass/uniline"
+	"github.com/tiborvass/uniline/ansi"
+	"github.com/tiborvass/uniline/vtest"
+)
+
+// loadHistory returns the lines of the history file specified by filename, as loaded by a new Scanner.
+func loadHistory(t *testing.T, filename string) []string {
+	t.Helper()
+	s := uniline.NewScanner(strings.NewReader(""), nil, nil, nil)
+	if err := s.LoadHistory(filename); err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, e := range s.HistoryEntries() {
+		lines = append(lines, e.Line)
+	}
+	return lines
+}
+
+func TestHistoryPolicy(t *testing.T) {
+	s := uniline.NewScannerWithConfig(uniline.Config{
+		Input:          vtest.New(40, 10),
+		HistoryLimit:   3,
+		HistoryControl: uniline.IgnoreBoth | uniline.EraseDups,
+		HistoryFilter:  func(line string) bool { return line != "secret" },
+	})
+	for _, l := range []string{"a", "b", "b", " c", "secret", "a", "d", "e"} {
+		s.AddToHistory(l)
+	}
+	fn := filepath.Join(t.TempDir(), "history")
+	if err := s.SaveHistory(fn); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(loadHistory(t, fn), "|"); got != "a|d|e" {
+		t.Fatalf("saved %q", got)
+	}
+
+	// the limit also applies to the loaded history
+	if err := os.WriteFile(fn, []byte(strings.Repeat("x\n", 10)+"y\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.LoadHistory(fn); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveHistory(fn); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(loadHistory(t, fn), "|"); got != "x|y" {
+		t.Fatalf("saved %q", got)
+	}
+}
+
+// countingHistory is a History counting the entries added to it.
+type countingHistory struct {
+	uniline.MemoryHistory
+	added int
+}
+
+func (h *countingHistory) Add(e uniline.HistoryEntry) {
+	h.added++
+	h.MemoryHistory.Add(e)
+}
+
+func TestHistoryStorage(t *testing.T) {
+	h := new(countingHistory)
+	term := vtest.New(40, 10)
+	s := uniline.NewScannerWithConfig(uniline.Config{Input: term, History: h})
+	s.AddToHistory("foo")
+	s.AddToHistory("bar")
+	term.Type("cur", ansi.UP, "2", ansi.UP, ansi.DOWN, ansi.DOWN, ansi.UP, ansi.UP, "42", ansi.CARRIAGE_RETURN)
+	if !s.Scan("> ") || s.Text() != "foo42" {
+		t.Fatalf("got %q", s.Text())
+	}
+	s.AddToHistory(s.Text())
+	if h.added != 3 || h.Len() != 3 || s.History() != uniline.History(h) {
+		t.Fatalf("%d entries added, %d entries", h.added, h.Len())
+	}
+}
+
+func TestMemoryHistorySearch(t *testing.T) {
+	h := new(uniline.MemoryHistory)
+	for _, l := range []string{"foo", "bar", "foo42"} {
+		h.Add(uniline.HistoryEntry{Line: l})
+	}
+	for _, tc := range []struct {
+		query string
+		dir   uniline.Direction
+		from  int
+		want  int
+	}{
+		{"o", uniline.Backward, 2, 2},
+		{"o", uniline.Backward, 1, 0},
+		{"o", uniline.Forward, 1, 2},
+		{"bar", uniline.Forward, 2, -1},
+		{"x", uniline.Backward, 2, -1},
+	} {
+		if got := h.Search(tc.query, tc.dir, tc.from); got != tc.want {
+			t.Errorf("Search(%q, %d, %d) = %d, want %d", tc.query, tc.dir, tc.from, got, tc.want)
+		}
+	}
+	h.Remove(0)
+	if h.Len() != 2 || h.At(0).Line != "bar" {
+		t.Fatalf("after Remove: %d entries, first %q", h.Len(), h.At(0).Line)
+	}
+}
+
+func TestHistorySearchPrefix(t *testing.T) {
+	h := new(uniline.MemoryHistory)
+	for _, l := range []string{"git status", "ls", "git log", "git log", "make"} {
+		h.Add(uniline.HistoryEntry{Line: l})
+	}
+	cfg := uniline.Config{History: h}
+	// duplicates are skipped, and the cursor stays after the prefix
+	s, _ := scan(t, cfg, "git", ansi.PAGE_UP, ansi.PAGE_UP, "X", ansi.CARRIAGE_RETURN)
+	if s.Text() != "gitX status" {
+		t.Fatalf("got %q", s.Text())
+	}
+	s, _ = scan(t, cfg, "git", ansi.PAGE_UP, ansi.PAGE_UP, ansi.PAGE_DOWN, ansi.PAGE_DOWN, ansi.CARRIAGE_RETURN)
+	if s.Text() != "git" {
+		t.Fatalf("got %q", s.Text())
+	}
+	s, term := scan(t, cfg, "x", ansi.PAGE_UP, ansi.CARRIAGE_RETURN)
+	if s.Text() != "x" || term.Bells() != 1 {
+		t.Fatalf("got %q with %d bells", s.Text(), term.Bells())
+	}
+}
+
+func TestOperateAndGetNext(t *testing.T) {
+	term := vtest.New(40, 10)
+	s := uniline.NewScannerWithConfig(uniline.Config{Input: term, HistoryLimit: 3})
+	for _, l := range []string{"a", "b", "c"} {
+		s.AddToHistory(l)
+	}
+	// the entries are replayed while the history is full, the oldest one being evicted each time
+	term.Type(ansi.UP, ansi.UP, ansi.UP, ansi.CTRL_O, ansi.CTRL_O, "!", ansi.CARRIAGE_RETURN)
+	var got []string
+	for s.Scan("> ") {
+		got = append(got, s.Text())
+		s.AddToHistory(s.Text())
+	}
+	if strings.Join(got, "|") != "a|b|c!" {
+		t.Fatalf("got %q", got)
+	}
+}
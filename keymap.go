@@ -26,13 +26,31 @@ func DefaultKeymap() Keymap {
 		ansi.CTRL_F: (*Core).MoveRight,
 		ansi.CTRL_P: (*Core).HistoryBack,
 		ansi.CTRL_N: (*Core).HistoryForward,
+		ansi.CTRL_R: (*Core).FuzzyFindHistory,
+		ansi.CTRL_O: (*Core).OperateAndGetNext,
 
 		ansi.CTRL_U: (*Core).CutLineLeft,
 		ansi.CTRL_K: (*Core).CutLineRight,
 		ansi.CTRL_A: (*Core).MoveBeginning,
 		ansi.CTRL_E: (*Core).MoveEnd,
-		ansi.CTRL_W: (*Core).CutPrevWord,
+		ansi.CTRL_W: (*Core).CutRegionOrPrevWord,
 		ansi.CTRL_Y: (*Core).Paste,
+		ansi.CTRL_V: (*Core).QuotedInsert,
+
+		ansi.TAB: (*Core).Complete,
+
+		ansi.CTRL_SPACE: (*Core).SetMark,
+
+		ansi.CTRL_RIGHT_BRACKET: (*Core).CharacterSearch,
+
+		// Ctrl-X prefixed sequences
+		ansi.CTRL_X: nil,
+
+		ansi.CTRL_X_CTRL_E:      (*Core).EditAndEnter,
+		ansi.CTRL_X_OPEN_PAREN:  (*Core).StartKbdMacro,
+		ansi.CTRL_X_CLOSE_PAREN: (*Core).EndKbdMacro,
+		ansi.CTRL_X_E:           (*Core).CallLastKbdMacro,
+		ansi.CTRL_X_CTRL_X:      (*Core).SwapCursorAndMark,
 
 		// Escape sequences
 		ansi.START_ESCAPE_SEQ: nil,
@@ -41,6 +59,29 @@ func DefaultKeymap() Keymap {
 		ansi.META_LEFT:  (*Core).MoveWordLeft,
 		ansi.META_F:     (*Core).MoveWordRight,
 		ansi.META_RIGHT: (*Core).MoveWordRight,
+		ansi.META_CARET: (*Core).ExpandHistory,
+		ansi.META_DOT:   (*Core).YankLastArg,
+		ansi.META_UNDER: (*Core).YankLastArg,
+		ansi.META_U:     (*Core).UpcaseWord,
+		ansi.META_L:     (*Core).DowncaseWord,
+		ansi.META_C:     (*Core).CapitalizeWord,
+		ansi.META_T:     (*Core).TransposeWords,
+		ansi.META_W:     (*Core).CopyRegion,
+
+		ansi.META_CTRL_RIGHT_BRACKET: (*Core).CharacterSearchBackward,
+
+		// numeric arguments
+		ansi.META_MINUS: (*Core).NegativeArgument,
+		ansi.META_0:     (*Core).DigitArgument,
+		ansi.META_1:     (*Core).DigitArgument,
+		ansi.META_2:     (*Core).DigitArgument,
+		ansi.META_3:     (*Core).DigitArgument,
+		ansi.META_4:     (*Core).DigitArgument,
+		ansi.META_5:     (*Core).DigitArgument,
+		ansi.META_6:     (*Core).DigitArgument,
+		ansi.META_7:     (*Core).DigitArgument,
+		ansi.META_8:     (*Core).DigitArgument,
+		ansi.META_9:     (*Core).DigitArgument,
 
 		ansi.LEFT:  (*Core).MoveLeft,
 		ansi.RIGHT: (*Core).MoveRight,
@@ -50,7 +91,11 @@ func DefaultKeymap() Keymap {
 		// Extended escape
 		ansi.START_EXTENDED_ESCAPE_SEQ:   nil,
 		ansi.START_EXTENDED_ESCAPE_SEQ_3: nil,
+		ansi.START_EXTENDED_ESCAPE_SEQ_5: nil,
+		ansi.START_EXTENDED_ESCAPE_SEQ_6: nil,
 
-		ansi.DELETE: (*Core).Delete, // Delete key
+		ansi.DELETE:    (*Core).Delete, // Delete key
+		ansi.PAGE_UP:   (*Core).HistorySearchBackward,
+		ansi.PAGE_DOWN: (*Core).HistorySearchForward,
 	}
 }
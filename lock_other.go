@@ -0,0 +1,8 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+
+package uniline
+
+// lockFile fails on this platform, where files are not locked, c.f. ShareHistory.
+func lockFile(filename string) (unlock func(), err error) {
+	return nil, ErrLockUnsupported
+}
@@ -0,0 +1,25 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package uniline
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive lock on filename+".lock", creating it if needed, and returns a function releasing the lock.
+// A separate file is locked, since the locked file itself can be replaced, c.f. writeHistoryAtomic.
+func lockFile(filename string) (unlock func(), err error) {
+	f, err := os.OpenFile(filename+".lock", os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
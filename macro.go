@@ -0,0 +1,165 @@
+package uniline
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tiborvass/uniline/ansi"
+)
+
+// macroState holds the keyboard macros: the one being recorded, the last one, and the named ones.
+type macroState struct {
+	recording bool
+	keys      []ansi.Code // keys recorded so far
+	last      []ansi.Code
+	named     map[string][]ansi.Code
+	pending   []ansi.Code // keys being replayed, read before the input
+}
+
+// StartKbdMacro starts recording the keys typed, until EndKbdMacro.
+func (core *Core) StartKbdMacro() {
+	if core.macro.recording {
+		core.Bell()
+		return
+	}
+	core.macro.recording = true
+	core.macro.keys = nil
+}
+
+// EndKbdMacro stops recording the keys typed, which make the macro replayed by CallLastKbdMacro.
+func (core *Core) EndKbdMacro() {
+	if !core.macro.recording {
+		core.Bell()
+		return
+	}
+	core.macro.recording = false
+	// leave out the key ending the macro
+	core.macro.last = core.macro.keys[:len(core.macro.keys)-1]
+	core.macro.keys = nil
+}
+
+// CallLastKbdMacro replays the keys of the last recorded macro, as many times as the numeric argument (c.f. Count).
+// The keys are handled like typed keys, so a macro can edit several lines if it contains Enter.
+func (core *Core) CallLastKbdMacro() {
+	if core.macro.recording {
+		// record the keys of the macro instead
+		core.macro.keys = core.macro.keys[:len(core.macro.keys)-1]
+	}
+	core.replay(core.macro.last)
+}
+
+// CallMacro returns a Keymap function replaying the macro named name, c.f. Scanner.SetMacro.
+//
+//	km[ansi.Code("\x1bm")] = uniline.CallMacro("quote")
+func CallMacro(name string) func(*Core) {
+	return func(core *Core) {
+		if core.macro.recording {
+			core.macro.keys = core.macro.keys[:len(core.macro.keys)-1]
+		}
+		core.replay(core.macro.named[name])
+	}
+}
+
+// replay queues keys to be read before the input, Count times.
+func (core *Core) replay(keys []ansi.Code) {
+	n := core.Count()
+	if len(keys) == 0 || n <= 0 {
+		core.Bell()
+		return
+	}
+	var pending []ansi.Code
+	for ; n > 0; n-- {
+		pending = append(pending, keys...)
+	}
+	core.macro.pending = append(pending, core.macro.pending...)
+}
+
+// LastMacro returns the keys of the last macro recorded with Ctrl-X ( and Ctrl-X ).
+func (s *Scanner) LastMacro() []ansi.Code {
+	return append([]ansi.Code(nil), s.macro.last...)
+}
+
+// Macro returns the keys of the macro named name.
+func (s *Scanner) Macro(name string) (keys []ansi.Code, ok bool) {
+	keys, ok = s.macro.named[name]
+	return append([]ansi.Code(nil), keys...), ok
+}
+
+// SetMacro names a macro made of keys, which can then be saved with SaveMacros, and bound to a key with CallMacro.
+// For instance, the last recorded macro can be named with:
+//
+//	s.SetMacro("quote", s.LastMacro())
+func (s *Scanner) SetMacro(name string, keys []ansi.Code) {
+	if s.macro.named == nil {
+		s.macro.named = make(map[string][]ansi.Code)
+	}
+	s.macro.named[name] = append([]ansi.Code(nil), keys...)
+}
+
+// SaveMacros saves the named macros to a file specified by filename, one per line:
+// the name of the macro and its keys, as Go quoted strings separated by a space.
+func (s *Scanner) SaveMacros(filename string) error {
+	names := make([]string, 0, len(s.macro.named))
+	for name := range s.macro.named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		var keys strings.Builder
+		for _, k := range s.macro.named[name] {
+			keys.WriteString(string(k))
+		}
+		fmt.Fprintf(&b, "%s %s\n", strconv.Quote(name), strconv.Quote(keys.String()))
+	}
+	return os.WriteFile(filename, []byte(b.String()), 0600)
+}
+
+// LoadMacros loads named macros from a file specified by filename, c.f. SaveMacros.
+// The keys of the macros are split according to the Keymap of the Scanner.
+func (s *Scanner) LoadMacros(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		quoted, err := strconv.QuotedPrefix(line)
+		if err != nil || !strings.HasPrefix(line[len(quoted):], " ") {
+			return fmt.Errorf("invalid macro line %q", line)
+		}
+		name, _ := strconv.Unquote(quoted)
+		keys, err := strconv.Unquote(line[len(quoted)+1:])
+		if err != nil {
+			return fmt.Errorf("invalid macro line %q: %v", line, err)
+		}
+		s.SetMacro(name, s.splitKeys(keys))
+	}
+	return scanner.Err()
+}
+
+// splitKeys splits p into keys, like readKey.
+func (core *Core) splitKeys(p string) (keys []ansi.Code) {
+	var key string
+	for _, r := range p {
+		key += string(r)
+		if scanFun, ok := core.km[ansi.Code(key)]; ok && scanFun == nil {
+			continue
+		}
+		keys = append(keys, ansi.Code(key))
+		key = ""
+	}
+	if len(key) > 0 {
+		keys = append(keys, ansi.Code(key))
+	}
+	return keys
+}
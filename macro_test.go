@@ -0,0 +1,70 @@
+package uniline_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/tiborvass/uniline"
+	"github.com/tiborvass/uniline/ansi"
+	"github.com/tiborvass/uniline/vtest"
+)
+
+func TestMacro(t *testing.T) {
+	s, _ := scan(t, uniline.Config{}, "foo", ansi.CTRL_X_OPEN_PAREN, ansi.CTRL_A, "[", ansi.CTRL_E, "]", ansi.CTRL_X_CLOSE_PAREN, ansi.CTRL_X_E, ansi.CARRIAGE_RETURN)
+	if s.Text() != "[[foo]]" {
+		t.Fatalf("got %q", s.Text())
+	}
+	want := []ansi.Code{ansi.CTRL_A, "[", ansi.CTRL_E, "]"}
+	if !reflect.DeepEqual(s.LastMacro(), want) {
+		t.Fatalf("recorded %q", s.LastMacro())
+	}
+
+	s.SetMacro("wrap", s.LastMacro())
+	fn := filepath.Join(t.TempDir(), "macros")
+	if err := s.SaveMacros(fn); err != nil {
+		t.Fatal(err)
+	}
+	term := vtest.New(40, 10)
+	km := uniline.DefaultKeymap()
+	km["\x1bm"] = uniline.CallMacro("wrap")
+	s = uniline.NewScannerWithConfig(uniline.Config{Input: term, Keymap: km})
+	if err := s.LoadMacros(fn); err != nil {
+		t.Fatal(err)
+	}
+	if m, _ := s.Macro("wrap"); !reflect.DeepEqual(m, want) {
+		t.Fatalf("loaded %q", m)
+	}
+	// the recording goes on across lines
+	term.Type("x", ansi.META_2, "\x1bm", ansi.CARRIAGE_RETURN, "a", ansi.CTRL_X_OPEN_PAREN, "b", ansi.CARRIAGE_RETURN, ansi.CTRL_X_CLOSE_PAREN, ansi.CTRL_X_E, "c", ansi.CARRIAGE_RETURN)
+	for _, want := range []string{"[[x]]", "ab", "b", "c"} {
+		if !s.Scan("> ") || s.Text() != want {
+			t.Fatalf("got %q, want %q", s.Text(), want)
+		}
+	}
+}
+
+func TestSaveMacros(t *testing.T) {
+	s := uniline.NewScannerWithConfig(uniline.Config{Input: vtest.New(40, 10)})
+	macros := map[string][]ansi.Code{
+		"wrap":         {ansi.CTRL_A, "[", ansi.CTRL_E, "]"},
+		"two words":    {"a", " ", "b"},
+		"\"quoted\"\n": {ansi.CARRIAGE_RETURN},
+	}
+	for name, keys := range macros {
+		s.SetMacro(name, keys)
+	}
+	fn := filepath.Join(t.TempDir(), "macros")
+	if err := s.SaveMacros(fn); err != nil {
+		t.Fatal(err)
+	}
+	s = uniline.NewScannerWithConfig(uniline.Config{Input: vtest.New(40, 10)})
+	if err := s.LoadMacros(fn); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range macros {
+		if keys, ok := s.Macro(name); !ok || !reflect.DeepEqual(keys, want) {
+			t.Errorf("%q: loaded %q, want %q", name, keys, want)
+		}
+	}
+}
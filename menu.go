@@ -0,0 +1,193 @@
+package uniline
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tiborvass/uniline/ansi"
+)
+
+// menuHeight is the maximum number of rows of candidates shown at once by the completion menu.
+const menuHeight = 10
+
+// menuQueryItems is the number of candidates above which the user is asked whether to show the completion menu.
+const menuQueryItems = 100
+
+// menuLayout is the grid of the completion menu, filled column by column.
+type menuLayout struct {
+	rows, cols int
+	height     int // number of rows shown at once
+	textWidth  int // width of the candidates
+	descWidth  int // width of their descriptions, 0 if there are none
+}
+
+// completionMenu lists the candidates replacing the text from start to the cursor, c.f. Complete.
+// typed is the text as the user typed it, before the common prefix of the candidates was inserted, restored by Ctrl-G.
+func (core *Core) completionMenu(start position, typed text, candidates []Candidate) {
+	if len(candidates) > menuQueryItems && !core.confirm(fmt.Sprintf("Display all %d possibilities? (y/n)", len(candidates))) {
+		return
+	}
+	layout := core.menuLayout(candidates)
+	n := len(candidates)
+	selected, first := -1, 0
+	// move moves the selection by step, within the candidates
+	move := func(step int) {
+		switch {
+		case selected < 0:
+			selected = 0
+		case selected+step < 0:
+			selected = 0
+		case selected+step >= n:
+			selected = n - 1
+		default:
+			selected += step
+		}
+	}
+	for {
+		if selected >= 0 {
+			if row := selected % layout.rows; row < first {
+				first = row
+			} else if row >= first+layout.height {
+				first = row - layout.height + 1
+			}
+		}
+		core.drawBelow(core.menuRows(candidates, layout, selected, first))
+
+		key, ok := core.readKey()
+		if !ok {
+			core.clearBelow()
+			return
+		}
+		previous := selected
+		switch key {
+		case ansi.TAB, ansi.DOWN, ansi.CTRL_N:
+			selected = (selected + 1) % n
+		case ansi.SHIFT_TAB, ansi.UP, ansi.CTRL_P:
+			if selected <= 0 {
+				selected = n - 1
+			} else {
+				selected--
+			}
+		case ansi.RIGHT:
+			move(layout.rows)
+		case ansi.LEFT:
+			move(-layout.rows)
+		case ansi.PAGE_DOWN:
+			move(layout.height)
+		case ansi.PAGE_UP:
+			move(-layout.height)
+		case ansi.CTRL_G:
+			core.replace(start, core.pos, typed)
+			core.clearBelow()
+			return
+		case ansi.NEWLINE, ansi.CARRIAGE_RETURN:
+			if selected >= 0 {
+				core.clearBelow()
+				return
+			}
+			fallthrough
+		default:
+			core.clearBelow()
+			core.dispatch(key)
+			return
+		}
+		if selected != previous {
+			core.replace(start, core.pos, textFromString(candidates[selected].Text))
+		}
+	}
+}
+
+// confirm asks question below the edit line, and reports whether the answer is yes.
+func (core *Core) confirm(question string) bool {
+	core.drawBelow([]string{string(fitRow(question, core.cols-1))})
+	key, ok := core.readKey()
+	core.clearBelow()
+	return ok && (key == "y" || key == "Y" || key == " ")
+}
+
+// menuLayout returns the grid with the most columns fitting in the terminal,
+// descriptions being truncated if a candidate and its description do not fit.
+// At most menuHeight rows are shown at once, fewer if the terminal is not high enough.
+func (core *Core) menuLayout(candidates []Candidate) menuLayout {
+	var l menuLayout
+	for _, c := range candidates {
+		if w := rowWidth(c.Text); w > l.textWidth {
+			l.textWidth = w
+		}
+		if w := rowWidth(c.Description); w > l.descWidth {
+			l.descWidth = w
+		}
+	}
+	width := core.cols - 1
+	if l.textWidth > width {
+		l.textWidth = width
+	}
+	itemWidth := l.textWidth
+	if l.descWidth > 0 {
+		if l.textWidth+2+l.descWidth > width {
+			l.descWidth = width - l.textWidth - 2
+		}
+		if l.descWidth > 0 {
+			itemWidth += 2 + l.descWidth
+		} else {
+			l.descWidth = 0
+		}
+	}
+	l.cols = (width + 2) / (itemWidth + 2)
+	if l.cols < 1 {
+		l.cols = 1
+	}
+	l.rows = (len(candidates) + l.cols - 1) / l.cols
+	l.height = l.rows
+	if h := core.belowHeight(); l.height > menuHeight || l.height > h {
+		// leave room for the row telling which rows are shown
+		l.height = menuHeight
+		if l.height > h-1 {
+			l.height = h - 1
+		}
+		if l.height < 1 {
+			l.height = 1
+		}
+	}
+	return l
+}
+
+// menuRows renders the rows of the completion menu from first, the descriptions being dimmed and the selected candidate highlighted.
+// If not all the rows fit, a last row tells which ones are shown.
+func (core *Core) menuRows(candidates []Candidate, l menuLayout, selected, first int) []string {
+	var rows []string
+	last := first + l.height
+	if last > l.rows {
+		last = l.rows
+	}
+	for r := first; r < last; r++ {
+		var b strings.Builder
+		for c := 0; c < l.cols; c++ {
+			i := c*l.rows + r
+			if i >= len(candidates) {
+				break
+			}
+			if c > 0 {
+				b.WriteString("  ")
+			}
+			if i == selected {
+				b.WriteString(ansi.Reverse)
+			}
+			b.WriteString(fitCell(candidates[i].Text, l.textWidth))
+			if i == selected {
+				b.WriteString(ansi.ResetAttributes)
+			}
+			if l.descWidth > 0 {
+				b.WriteString("  ")
+				b.WriteString(ansi.Dim)
+				b.WriteString(fitCell(candidates[i].Description, l.descWidth))
+				b.WriteString(ansi.ResetAttributes)
+			}
+		}
+		rows = append(rows, b.String())
+	}
+	if l.rows > l.height {
+		rows = append(rows, ansi.Dim+string(fitRow(fmt.Sprintf("rows %d-%d of %d", first+1, last, l.rows), core.cols-1))+ansi.ResetAttributes)
+	}
+	return rows
+}
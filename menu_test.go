@@ -0,0 +1,72 @@
+package uniline_test
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tiborvass/uniline"
+	"github.com/tiborvass/uniline/ansi"
+)
+
+func TestCompletionMenu(t *testing.T) {
+	fs := flag.NewFlagSet("add", flag.ContinueOnError)
+	fs.Bool("fetch", false, "fetch the remote")
+	fs.Bool("force", false, "force it")
+	cfg := uniline.Config{Completer: &uniline.Command{Subcommands: []*uniline.Command{
+		{Name: "add", Flags: fs, Usage: "adds"},
+		{Name: "apply", Usage: "applies"},
+	}}}
+
+	_, term := scan(t, cfg, "a", ansi.TAB)
+	if len(term.screen) < 2 || term.screen[1] != "add    adds     apply  applies" {
+		t.Fatalf("candidates not listed: %q", term.screen)
+	}
+	s, _ := scan(t, cfg, "a", ansi.TAB, ansi.TAB, ansi.CARRIAGE_RETURN, " -", ansi.TAB, ansi.SHIFT_TAB, " x", ansi.CARRIAGE_RETURN)
+	if s.Text() != "add --force x" {
+		t.Fatalf("got %q", s.Text())
+	}
+	s, _ = scan(t, cfg, "a", ansi.TAB, ansi.TAB, ansi.TAB, ansi.CTRL_G, ansi.CARRIAGE_RETURN)
+	if s.Text() != "a" {
+		t.Fatalf("got %q after Ctrl-G", s.Text())
+	}
+}
+
+func TestCompletionMenuRestoresTypedText(t *testing.T) {
+	// the common prefix "file" is inserted, but Ctrl-G restores "fi"
+	cfg := uniline.Config{Completer: words(false, "file1", "file2")}
+	s, _ := scan(t, cfg, "fi", ansi.TAB, ansi.TAB, ansi.CTRL_G, ansi.CARRIAGE_RETURN)
+	if s.Text() != "fi" {
+		t.Fatalf("got %q after Ctrl-G, want %q", s.Text(), "fi")
+	}
+}
+
+func TestCompletionMenuQuery(t *testing.T) {
+	var many []string
+	for i := 0; i < 150; i++ {
+		many = append(many, fmt.Sprintf("w%03d", i))
+	}
+	cfg := uniline.Config{Completer: uniline.CompleterFunc(func(line string, pos int) (int, []string) { return 0, many })}
+	_, term := scan(t, cfg, ansi.TAB)
+	if len(term.screen) < 2 || !strings.HasPrefix(term.screen[1], "Display all 150 possibilities?") {
+		t.Fatalf("no query: %q", term.screen)
+	}
+	s, term := scan(t, cfg, ansi.TAB, "n", ansi.CARRIAGE_RETURN)
+	if s.Text() != "w" || strings.Contains(strings.Join(term.screen, "\n"), "w000") {
+		t.Fatalf("got %q, screen %q", s.Text(), term.screen)
+	}
+}
+
+func TestCompletionMenuSmallTerminal(t *testing.T) {
+	var many []string
+	for i := 0; i < 80; i++ {
+		many = append(many, fmt.Sprintf("w%03d", i))
+	}
+	cfg := uniline.Config{Completer: uniline.CompleterFunc(func(line string, pos int) (int, []string) { return 0, many })}
+	// 14 rows of candidates do not fit below the edit line of the 10 rows terminal
+	_, term := scan(t, cfg, ansi.TAB)
+	if len(term.screen) != 10 || term.screen[0] != "> w0" || term.screen[9] != "rows 1-8 of 14" || term.y != 0 {
+		t.Fatalf("got %q with the cursor on row %d", term.screen, term.y)
+	}
+}
@@ -0,0 +1,99 @@
+package uniline
+
+import (
+	"fmt"
+
+	"github.com/tiborvass/uniline/ansi"
+)
+
+// maxCount is the largest numeric argument, like in readline.
+const maxCount = 1000000
+
+// numericArg is the numeric argument typed before a command, c.f. Count.
+type numericArg struct {
+	active    bool
+	typed     bool // whether value was typed, or set by UniversalArgument
+	universal bool // whether value was set by UniversalArgument, in which case the next digit replaces it
+	negative  bool
+	value     int
+}
+
+// Count returns the numeric argument of the current command, typed before it with Meta-digits and Meta-minus
+// (c.f. DigitArgument), or 1 if there is none.
+// The commands supporting it repeat themselves Count times, and reverse their direction if it is negative.
+func (core *Core) Count() int {
+	n := 1
+	if core.arg.typed {
+		n = core.arg.value
+	}
+	if core.arg.negative {
+		n = -n
+	}
+	return n
+}
+
+// DigitArgument adds the digit of the key it is bound to (e.g. Meta-3) to the numeric argument of the next command, c.f. Count.
+// Once a numeric argument is started, digits are added to it without Meta.
+func (core *Core) DigitArgument() {
+	if k := core.key[len(core.key)-1]; k >= '0' && k <= '9' {
+		core.digit(int(k - '0'))
+	}
+}
+
+// NegativeArgument negates the numeric argument of the next command, c.f. Count.
+func (core *Core) NegativeArgument() {
+	core.arg.active = true
+	core.arg.negative = !core.arg.negative
+	core.argument()
+}
+
+// UniversalArgument starts a numeric argument of 4, multiplied by 4 each time it is called again, c.f. Count.
+// Like in readline, it is not bound by default.
+func (core *Core) UniversalArgument() {
+	a := &core.arg
+	if !a.active {
+		a.value = 4
+		a.active, a.typed, a.universal = true, true, true
+	} else if a.universal {
+		a.value *= 4
+	}
+	core.argument()
+}
+
+func (core *Core) digit(d int) {
+	a := &core.arg
+	if !a.typed || a.universal {
+		a.value = d
+	} else {
+		a.value = a.value*10 + d
+	}
+	a.active, a.typed, a.universal = true, true, false
+	core.argument()
+}
+
+// argDigit adds key to the numeric argument if it is a digit, and reports whether it did.
+func (core *Core) argDigit(key ansi.Code) bool {
+	if len(key) != 1 || key[0] < '0' || key[0] > '9' {
+		return false
+	}
+	core.digit(int(key[0] - '0'))
+	return true
+}
+
+// argument keeps the numeric argument for the next command, and shows it below the line.
+// The bell rings if the argument exceeds maxCount, which it is capped at.
+func (core *Core) argument() {
+	if core.arg.value > maxCount {
+		core.arg.value = maxCount
+		core.Bell()
+	}
+	core.command = cmdArgument
+	core.drawBelow([]string{fmt.Sprintf("(arg: %d)", core.Count())})
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
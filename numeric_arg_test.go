@@ -0,0 +1,59 @@
+package uniline_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tiborvass/uniline"
+	"github.com/tiborvass/uniline/ansi"
+)
+
+func TestNumericArgument(t *testing.T) {
+	for _, tc := range []struct {
+		history []string
+		keys    []ansi.Code
+		want    string
+	}{
+		{nil, []ansi.Code{"abcdef", ansi.META_3, ansi.CTRL_B, "X"}, "abcXdef"},
+		{nil, []ansi.Code{"abcdef", ansi.META_1, "2", ansi.CTRL_B, "X"}, "Xabcdef"},
+		{nil, []ansi.Code{"abcdef", ansi.META_MINUS, ansi.META_2, ansi.CTRL_B, "X"}, "abcdefX"},
+		{nil, []ansi.Code{"abcdef", ansi.CTRL_A, ansi.META_MINUS, ansi.META_2, ansi.CTRL_B, "X"}, "abXcdef"},
+		{nil, []ansi.Code{"abcdef", ansi.META_2, ansi.BACKSPACE}, "abcd"},
+		{nil, []ansi.Code{"abcdef", ansi.CTRL_A, ansi.META_2, ansi.DELETE}, "cdef"},
+		{nil, []ansi.Code{"a b c d", ansi.META_2, ansi.CTRL_W}, "a b "},
+		{nil, []ansi.Code{"a b c d", ansi.CTRL_A, ansi.META_MINUS, ansi.META_2, ansi.CTRL_W, ansi.CTRL_Y, ansi.CTRL_Y}, "a ba b c d"},
+		{nil, []ansi.Code{"x", ansi.META_3, "y"}, "xyyy"},
+		{nil, []ansi.Code{"a b c", ansi.CTRL_A, ansi.META_2, ansi.META_U}, "A B c"},
+		{nil, []ansi.Code{"a b c", ansi.META_MINUS, ansi.META_2, ansi.META_U, "!"}, "a B C!"},
+		{[]string{"cmd one two three"}, []ansi.Code{ansi.META_1, ansi.META_DOT}, "one"},
+		{[]string{"cmd one two three"}, []ansi.Code{ansi.META_MINUS, ansi.META_2, ansi.META_DOT}, "two"},
+		{[]string{"a", "b", "c"}, []ansi.Code{ansi.META_2, ansi.UP}, "b"},
+		{nil, []ansi.Code{ansi.META_3, ansi.META_DOT, "x"}, "x"},
+	} {
+		h := new(uniline.MemoryHistory)
+		for _, l := range tc.history {
+			h.Add(uniline.HistoryEntry{Line: l})
+		}
+		s, term := scan(t, uniline.Config{History: h}, append(tc.keys, ansi.CARRIAGE_RETURN)...)
+		if s.Text() != tc.want || term.Line(0) != strings.TrimRight("> "+tc.want, " ") {
+			t.Errorf("%q: got %q, line %q, want %q", tc.keys, s.Text(), term.Line(0), tc.want)
+		}
+	}
+}
+
+func TestUniversalArgument(t *testing.T) {
+	km := uniline.DefaultKeymap()
+	km[ansi.CTRL_U] = (*uniline.Core).UniversalArgument
+	// Ctrl-U multiplies the count by 4, unless followed by digits
+	s, _ := scan(t, uniline.Config{Keymap: km}, ansi.CTRL_U, "x", ansi.CTRL_U, ansi.CTRL_U, "y", ansi.CTRL_U, "3", "z", ansi.CARRIAGE_RETURN)
+	if want := strings.Repeat("x", 4) + strings.Repeat("y", 16) + "zzz"; s.Text() != want {
+		t.Fatalf("got %q, want %q", s.Text(), want)
+	}
+}
+
+func TestNumericArgumentLimit(t *testing.T) {
+	_, term := scan(t, uniline.Config{}, ansi.META_1, "0000000")
+	if len(term.screen) < 2 || term.screen[1] != "(arg: 1000000)" || term.Bells() != 1 {
+		t.Fatalf("got %q with %d bells", term.screen, term.Bells())
+	}
+}
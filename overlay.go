@@ -0,0 +1,85 @@
+package uniline
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/tiborvass/uniline/ansi"
+)
+
+// drawBelow draws rows below the edit line, replacing the ones drawn before, and puts the cursor back on the edit line.
+// The rows should fit in the width of the terminal, c.f. fitRow, and in its height, c.f. belowHeight:
+// the rows that do not are left out, since the edit line would scroll off the screen.
+func (core *Core) drawBelow(rows []string) {
+	if n := core.belowHeight(); len(rows) > n {
+		rows = rows[:n]
+	}
+	if len(rows) == 0 {
+		core.clearBelow()
+		return
+	}
+	var b strings.Builder
+	for _, row := range rows {
+		b.WriteString("\r\n")
+		b.WriteString(ansi.EraseToRight)
+		b.WriteString(row)
+	}
+	b.WriteString(ansi.EraseDown)
+	fmt.Fprintf(&b, ansi.MoveCursorUp, len(rows))
+	mustWrite(core.output.Write([]byte(b.String())))
+	core.below = len(rows)
+	core.Refresh()
+}
+
+// belowHeight returns the number of rows that drawBelow can draw, keeping the edit line on the screen.
+func (core *Core) belowHeight() int {
+	if core.rows < 1 {
+		return 0
+	}
+	return core.rows - 1
+}
+
+// clearBelow erases the rows drawn below the edit line.
+func (core *Core) clearBelow() {
+	if core.below == 0 {
+		return
+	}
+	mustWrite(fmt.Fprintf(core.output, "\r\n%s%s", ansi.EraseDown, fmt.Sprintf(ansi.MoveCursorUp, 1)))
+	core.below = 0
+	core.Refresh()
+}
+
+// fitRow returns the first runes of s fitting in cols columns, control characters being replaced by spaces.
+func fitRow(s string, cols int) (row []rune) {
+	var width int
+	for _, r := range s {
+		if unicode.IsControl(r) {
+			r = ' '
+		}
+		c := charFromRune(r)
+		if width+c.colLen > cols {
+			break
+		}
+		width += c.colLen
+		row = append(row, r)
+	}
+	return row
+}
+
+// fitCell returns s fitted in cols columns (c.f. fitRow), padded with spaces to exactly cols columns.
+func fitCell(s string, cols int) string {
+	row := fitRow(s, cols)
+	return string(row) + strings.Repeat(" ", cols-rowWidth(string(row)))
+}
+
+// rowWidth returns the number of columns taken by s once drawn by drawBelow, c.f. fitRow.
+func rowWidth(s string) (width int) {
+	for _, r := range s {
+		if unicode.IsControl(r) {
+			r = ' '
+		}
+		width += charFromRune(r).colLen
+	}
+	return width
+}
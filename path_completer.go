@@ -0,0 +1,89 @@
+package uniline
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PathCompleter is a Completer of file and directory paths, for shell-like arguments:
+// a leading ~ stands for the home directory, and the completed paths are escaped or quoted like the typed argument.
+// Directories are completed with a trailing slash, and hidden files are only completed once a dot has been typed.
+type PathCompleter struct {
+	// Dir is the directory relative paths are relative to, the working directory by default.
+	Dir string
+	// Extensions, if not empty, restricts the completed files to the ones with one of these extensions (e.g. ".go").
+	// Directories are always completed.
+	Extensions []string
+}
+
+// Complete implements Completer.
+func (pc *PathCompleter) Complete(line string, pos int) (start int, candidates []string) {
+	start, arg, quote := ArgumentAt(line, pos)
+
+	i := strings.LastIndexByte(arg, '/') + 1
+	dir, prefix := arg[:i], arg[i:]
+	if quote == 0 && arg == "~" {
+		return start, []string{"~/"}
+	}
+	// a leading ~ is kept as typed in the candidates
+	var home string
+	if quote == 0 && strings.HasPrefix(arg, "~/") {
+		home = "~"
+		dir = dir[1:]
+	}
+
+	path := dir
+	if len(home) > 0 {
+		h, err := os.UserHomeDir()
+		if err != nil {
+			return start, nil
+		}
+		path = h + dir
+	}
+	if len(path) == 0 {
+		path = "."
+	}
+	if !filepath.IsAbs(path) && len(pc.Dir) > 0 {
+		path = filepath.Join(pc.Dir, path)
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return start, nil
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, prefix) || (name[0] == '.' && !strings.HasPrefix(prefix, ".")) {
+			continue
+		}
+		isDir := e.IsDir()
+		if e.Type()&os.ModeSymlink != 0 {
+			if info, err := os.Stat(filepath.Join(path, name)); err == nil {
+				isDir = info.IsDir()
+			}
+		}
+		if isDir {
+			name += "/"
+		} else if !pc.hasExtension(name) {
+			continue
+		}
+		candidates = append(candidates, home+QuoteArgument(dir+name, quote))
+	}
+	sort.Strings(candidates)
+	return start, candidates
+}
+
+func (pc *PathCompleter) hasExtension(name string) bool {
+	if len(pc.Extensions) == 0 {
+		return true
+	}
+	ext := filepath.Ext(name)
+	for _, e := range pc.Extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
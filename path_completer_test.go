@@ -0,0 +1,56 @@
+package uniline_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/tiborvass/uniline"
+	"github.com/tiborvass/uniline/ansi"
+)
+
+func TestPathCompleter(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "my dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"my dir/a.go", "my dir/b.txt", ".hidden", "main.go"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	home := t.TempDir()
+	if err := os.Mkdir(filepath.Join(home, "projects"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", home)
+
+	pc := &uniline.PathCompleter{Dir: dir}
+	for _, tc := range []struct {
+		line       string
+		extensions []string
+		start      int
+		want       []string
+	}{
+		{"ls m", nil, 3, []string{"main.go", `my\ dir/`}},
+		{"ls .", nil, 3, []string{".hidden"}},
+		{`ls my\ d`, nil, 3, []string{`my\ dir/`}},
+		{`ls 'my d`, nil, 3, []string{`'my dir/`}},
+		{`ls "my dir/`, nil, 3, []string{`"my dir/a.go`, `"my dir/b.txt`}},
+		{`ls my\ dir/`, []string{".go"}, 3, []string{`my\ dir/a.go`}},
+		{"cd ~/pro", nil, 3, []string{"~/projects/"}},
+	} {
+		pc.Extensions = tc.extensions
+		start, candidates := pc.Complete(tc.line, len(tc.line))
+		if start != tc.start || !reflect.DeepEqual(candidates, tc.want) {
+			t.Errorf("%q: got %d %q, want %d %q", tc.line, start, candidates, tc.start, tc.want)
+		}
+	}
+
+	pc.Extensions = nil
+	s, _ := scan(t, uniline.Config{Completer: pc}, "cat my", ansi.TAB, "a", ansi.TAB, ansi.CARRIAGE_RETURN)
+	if s.Text() != `cat my\ dir/a.go` {
+		t.Fatalf("got %q", s.Text())
+	}
+}
@@ -0,0 +1,38 @@
+package uniline_test
+
+import (
+	"testing"
+
+	"github.com/tiborvass/uniline"
+	"github.com/tiborvass/uniline/ansi"
+)
+
+func TestQuotedInsert(t *testing.T) {
+	s, term := scan(t, uniline.Config{}, "a", ansi.CTRL_V, ansi.TAB, "b", ansi.CTRL_V, ansi.CTRL_A, ansi.CTRL_V, "\x1b", ansi.LEFT, ansi.LEFT, "X")
+	if s.Text() != "a\tbX\x01\x1b" {
+		t.Fatalf("got %q", s.Text())
+	}
+	if term.screen[0] != "> a^IbX^A^[" || term.x != 7 {
+		t.Fatalf("screen %q, cursor at %d", term.screen, term.x)
+	}
+
+	s, term = scan(t, uniline.Config{}, ansi.META_3, ansi.CTRL_V, ansi.BACKSPACE, ansi.BACKSPACE, ansi.CARRIAGE_RETURN)
+	if s.Text() != "\x7f\x7f" || term.Line(0) != "> ^?^?" {
+		t.Fatalf("got %q, line %q", s.Text(), term.Line(0))
+	}
+}
+
+func TestQuotedInsertC1(t *testing.T) {
+	// C1 controls have no caret notation, nor any width for wcwidth
+	s, term := scan(t, uniline.Config{}, "a", ansi.CTRL_V, "\u0085", "b", ansi.LEFT, ansi.LEFT)
+	if s.Text() != "a\u0085b" {
+		t.Fatalf("got %q", s.Text())
+	}
+	if term.screen[0] != "> a<85>b" || term.x != 3 {
+		t.Fatalf("screen %q, cursor at %d", term.screen, term.x)
+	}
+	s, term = scan(t, uniline.Config{}, ansi.CTRL_V, "\u009b", "x", ansi.BACKSPACE, ansi.BACKSPACE, "y", ansi.CARRIAGE_RETURN)
+	if s.Text() != "y" || term.Line(0) != "> y" {
+		t.Fatalf("got %q, line %q", s.Text(), term.Line(0))
+	}
+}
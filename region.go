@@ -0,0 +1,107 @@
+package uniline
+
+import "github.com/tiborvass/uniline/ansi"
+
+// markState is the mark, the region being the text between the mark and the cursor.
+type markState struct {
+	set    bool // whether the mark was set during the current Scan
+	active bool // whether the region is highlighted, until the line is edited
+	runes  int  // index of the character the mark is on
+}
+
+// SetMark sets the mark at the cursor and activates the region, or deactivates it if the mark was already there.
+func (core *Core) SetMark() {
+	if core.mark.active && core.mark.runes == core.pos.runes {
+		core.mark.active = false
+	} else {
+		core.mark = markState{set: true, active: true, runes: core.pos.runes}
+	}
+	core.Refresh()
+}
+
+// SwapCursorAndMark moves the cursor to the mark and the mark to the previous cursor position, activating the region.
+func (core *Core) SwapCursorAndMark() {
+	if !core.mark.set {
+		core.Bell()
+		return
+	}
+	pos := core.buf.RunePosition(core.markRunes())
+	core.mark.runes, core.mark.active = core.pos.runes, true
+	core.pos = pos
+	core.Refresh()
+}
+
+// CutRegion cuts the text between the mark and the cursor into the clipboard.
+func (core *Core) CutRegion() {
+	if !core.mark.set {
+		core.Bell()
+		return
+	}
+	from, to := core.region()
+	core.clipboard.text = core.buf.Slice(from, to).Clone()
+	core.clipboard.partial = false
+	core.mark.active = false
+	core.replace(from, to, text{})
+}
+
+// CopyRegion copies the text between the mark and the cursor into the clipboard.
+func (core *Core) CopyRegion() {
+	if !core.mark.set {
+		core.Bell()
+		return
+	}
+	from, to := core.region()
+	core.clipboard.text = core.buf.Slice(from, to).Clone()
+	core.clipboard.partial = false
+	core.mark.active = false
+	core.Refresh()
+}
+
+// CutRegionOrPrevWord is CutRegion if the region is active (c.f. SetMark), CutPrevWord otherwise.
+func (core *Core) CutRegionOrPrevWord() {
+	if core.mark.active {
+		core.CutRegion()
+	} else {
+		core.CutPrevWord()
+	}
+}
+
+// region returns the positions of the beginning and end of the region.
+func (core *Core) region() (from, to position) {
+	from, to = core.buf.RunePosition(core.markRunes()), core.pos
+	if from.runes > to.runes {
+		from, to = to, from
+	}
+	return from, to
+}
+
+// markRunes returns the index of the character the mark is on, within the line.
+func (core *Core) markRunes() int {
+	if core.mark.runes > len(core.buf.chars) {
+		return len(core.buf.chars)
+	}
+	return core.mark.runes
+}
+
+// highlight returns how the characters of the line between from and to are drawn (c.f. text.view), the active region being highlighted.
+func (core *Core) highlight(from, to position) []byte {
+	if !core.mark.active {
+		return core.buf.Slice(from, to).view()
+	}
+	start, end := core.region()
+	if start.runes < from.runes {
+		start = from
+	}
+	if end.runes > to.runes {
+		end = to
+	}
+	if start.runes >= end.runes {
+		return core.buf.Slice(from, to).view()
+	}
+	var p []byte
+	p = append(p, core.buf.Slice(from, start).view()...)
+	p = append(p, ansi.Reverse...)
+	p = append(p, core.buf.Slice(start, end).view()...)
+	p = append(p, ansi.ResetAttributes...)
+	return append(p, core.buf.Slice(end, to).view()...)
+}
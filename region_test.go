@@ -0,0 +1,50 @@
+package uniline_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/tiborvass/uniline"
+	"github.com/tiborvass/uniline/ansi"
+	"github.com/tiborvass/uniline/vtest"
+)
+
+func TestRegion(t *testing.T) {
+	for _, tc := range []struct {
+		keys []ansi.Code
+		want string
+	}{
+		{[]ansi.Code{ansi.CTRL_A, ansi.CTRL_SPACE, ansi.META_F, ansi.CTRL_W, ansi.CTRL_E, ansi.CTRL_Y}, " worldhello"},
+		{[]ansi.Code{ansi.CTRL_SPACE, ansi.META_B, ansi.META_W, ansi.CTRL_A, ansi.CTRL_Y}, "worldhello world"},
+		{[]ansi.Code{ansi.CTRL_A, ansi.CTRL_SPACE, ansi.CTRL_E, ansi.CTRL_X_CTRL_X, "X"}, "Xhello world"},
+		// editing deactivates the mark, Ctrl-W cutting a word again
+		{[]ansi.Code{ansi.CTRL_SPACE, "!", ansi.CTRL_W}, "hello "},
+	} {
+		s, term := scan(t, uniline.Config{}, append([]ansi.Code{"hello world"}, append(tc.keys, ansi.CARRIAGE_RETURN)...)...)
+		if s.Text() != tc.want || term.Line(0) != strings.TrimRight("> "+tc.want, " ") {
+			t.Errorf("%q: got %q, line %q, want %q", tc.keys, s.Text(), term.Line(0), tc.want)
+		}
+	}
+
+	// without a mark, there is no region
+	s, term := scan(t, uniline.Config{}, "a", ansi.CTRL_X_CTRL_X, ansi.META_W, ansi.CTRL_Y, ansi.CARRIAGE_RETURN)
+	if s.Text() != "a" || term.Bells() == 0 {
+		t.Errorf("got %q with %d bells", s.Text(), term.Bells())
+	}
+}
+
+func TestRegionHighlight(t *testing.T) {
+	term := vtest.New(40, 10)
+	var out bytes.Buffer
+	s := uniline.NewScannerWithConfig(uniline.Config{Input: term, Output: io.MultiWriter(term, &out)})
+	term.Type("hello world", ansi.CTRL_A, ansi.CTRL_SPACE, ansi.META_F, ansi.CARRIAGE_RETURN)
+	s.Scan("> ")
+	if s.Text() != "hello world" || term.Line(0) != "> hello world" {
+		t.Fatalf("got %q, line %q", s.Text(), term.Line(0))
+	}
+	if !strings.Contains(out.String(), ansi.Reverse+"hello"+ansi.ResetAttributes+" world") {
+		t.Fatalf("region not highlighted in %q", out.String())
+	}
+}
@@ -0,0 +1,229 @@
+package uniline
+
+// Readers and writers of the history files of common shells.
+// The entries can be imported in history with Scanner.AddHistoryEntry, and exported from Scanner.HistoryEntries.
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReadBashHistory reads the entries of a bash history file.
+// Lines of the form "#<epoch>", written by bash when HISTTIMEFORMAT is set, give the time of the following entry;
+// in that case, the lines up to the next timestamp are part of the same (multi-line) entry.
+func ReadBashHistory(r io.Reader) ([]HistoryEntry, error) {
+	var entries []HistoryEntry
+	var t time.Time
+	var timestamped, continued bool
+	scanner := newHistoryScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if epoch, ok := bashTimestamp(line); ok {
+			t = time.Unix(epoch, 0)
+			timestamped = true
+			continued = false
+			continue
+		}
+		if continued {
+			entries[len(entries)-1].Line += "\n" + line
+			continue
+		}
+		entries = append(entries, HistoryEntry{Line: line, Time: t})
+		continued = timestamped
+	}
+	return entries, scanner.Err()
+}
+
+// WriteBashHistory writes entries in the format of bash's history file, with their time if they have one.
+// Since the lines following a timestamp are part of the same entry, if any entry has a time, every entry is preceded by one:
+// entries without a time get the time of the previous entry, or 0 if there is none.
+func WriteBashHistory(w io.Writer, entries []HistoryEntry) error {
+	timestamped := false
+	for _, e := range entries {
+		if !e.Time.IsZero() {
+			timestamped = true
+			break
+		}
+	}
+	bw := bufio.NewWriter(w)
+	var epoch int64
+	for _, e := range entries {
+		if !e.Time.IsZero() {
+			epoch = e.Time.Unix()
+		}
+		if timestamped {
+			fmt.Fprintf(bw, "#%d\n", epoch)
+		}
+		fmt.Fprintln(bw, e.Line)
+	}
+	return bw.Flush()
+}
+
+func bashTimestamp(line string) (epoch int64, ok bool) {
+	if len(line) < 2 || line[0] != '#' {
+		return 0, false
+	}
+	epoch, err := strconv.ParseInt(line[1:], 10, 64)
+	return epoch, err == nil
+}
+
+// ReadZshHistory reads the entries of a zsh history file, in the extended format (": <start>:<elapsed>;<command>")
+// written by zsh when EXTENDED_HISTORY is set, or in the simple one.
+// Lines ending with a backslash are continued on the next line.
+func ReadZshHistory(r io.Reader) ([]HistoryEntry, error) {
+	var entries []HistoryEntry
+	var e HistoryEntry
+	var continued bool
+	scanner := newHistoryScanner(r)
+	for scanner.Scan() {
+		line := zshUnmetafy(scanner.Text())
+		if continued {
+			e.Line += "\n"
+		} else {
+			e = zshEntry(line)
+			line = e.Line
+			e.Line = ""
+		}
+		continued = strings.HasSuffix(line, "\\")
+		if continued {
+			line = line[:len(line)-1]
+		}
+		e.Line += line
+		if !continued {
+			entries = append(entries, e)
+		}
+	}
+	if continued {
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// zshEntry parses the metadata of the extended format, if any.
+func zshEntry(line string) HistoryEntry {
+	if !strings.HasPrefix(line, ": ") {
+		return HistoryEntry{Line: line}
+	}
+	i := strings.IndexByte(line, ';')
+	if i < 0 {
+		return HistoryEntry{Line: line}
+	}
+	fields := strings.SplitN(line[2:i], ":", 2)
+	start, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil || len(fields) != 2 {
+		return HistoryEntry{Line: line}
+	}
+	elapsed, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return HistoryEntry{Line: line}
+	}
+	return HistoryEntry{Line: line[i+1:], Time: time.Unix(start, 0), Duration: time.Duration(elapsed) * time.Second}
+}
+
+// WriteZshHistory writes entries in the extended format of zsh's history file.
+func WriteZshHistory(w io.Writer, entries []HistoryEntry) error {
+	bw := bufio.NewWriter(w)
+	for _, e := range entries {
+		var start int64
+		if !e.Time.IsZero() {
+			start = e.Time.Unix()
+		}
+		line := strings.Replace(e.Line, "\n", "\\\n", -1)
+		fmt.Fprintf(bw, ": %d:%d;%s\n", start, int64(e.Duration/time.Second), zshMetafy(line))
+	}
+	return bw.Flush()
+}
+
+// zsh escapes the bytes it uses internally (from Meta to Marker) and NUL, by prefixing them with Meta and xoring them with 32.
+const (
+	zshMeta   = 0x83
+	zshMarker = 0xa2
+)
+
+func zshUnmetafy(s string) string {
+	if strings.IndexByte(s, zshMeta) < 0 {
+		return s
+	}
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == zshMeta && i+1 < len(s) {
+			i++
+			b = append(b, s[i]^32)
+		} else {
+			b = append(b, s[i])
+		}
+	}
+	return string(b)
+}
+
+func zshMetafy(s string) string {
+	var b bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c == 0 || (c >= zshMeta && c <= zshMarker) {
+			b.WriteByte(zshMeta)
+			b.WriteByte(c ^ 32)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// ReadFishHistory reads the entries of a fish_history file.
+// Only the commands and their time are read, the paths are ignored.
+func ReadFishHistory(r io.Reader) ([]HistoryEntry, error) {
+	var entries []HistoryEntry
+	scanner := newHistoryScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "- cmd: "):
+			entries = append(entries, HistoryEntry{Line: fishUnescape(line[len("- cmd: "):])})
+		case strings.HasPrefix(line, "  when: ") && len(entries) > 0:
+			epoch, err := strconv.ParseInt(line[len("  when: "):], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid fish history line %q: %v", line, err)
+			}
+			entries[len(entries)-1].Time = time.Unix(epoch, 0)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// WriteFishHistory writes entries in the format of fish_history.
+func WriteFishHistory(w io.Writer, entries []HistoryEntry) error {
+	bw := bufio.NewWriter(w)
+	for _, e := range entries {
+		fmt.Fprintf(bw, "- cmd: %s\n", fishEscape(e.Line))
+		if !e.Time.IsZero() {
+			fmt.Fprintf(bw, "  when: %d\n", e.Time.Unix())
+		}
+	}
+	return bw.Flush()
+}
+
+// fish escapes backslashes and newlines in commands.
+var (
+	fishEscaper   = strings.NewReplacer("\\", "\\\\", "\n", "\\n")
+	fishUnescaper = strings.NewReplacer("\\\\", "\\", "\\n", "\n")
+)
+
+func fishEscape(s string) string {
+	return fishEscaper.Replace(s)
+}
+
+func fishUnescape(s string) string {
+	return fishUnescaper.Replace(s)
+}
+
+// newHistoryScanner returns a scanner of the lines read from r, accepting long lines.
+func newHistoryScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	return scanner
+}
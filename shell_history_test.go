@@ -0,0 +1,84 @@
+package uniline_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tiborvass/uniline"
+)
+
+var shellFormats = []struct {
+	name  string
+	write func(io.Writer, []uniline.HistoryEntry) error
+	read  func(io.Reader) ([]uniline.HistoryEntry, error)
+}{
+	{"bash", uniline.WriteBashHistory, uniline.ReadBashHistory},
+	{"zsh", uniline.WriteZshHistory, uniline.ReadZshHistory},
+	{"fish", uniline.WriteFishHistory, uniline.ReadFishHistory},
+}
+
+func TestShellHistoryRoundTrip(t *testing.T) {
+	entries := []uniline.HistoryEntry{
+		{Line: "ls -l", Time: time.Unix(100, 0), Duration: 2 * time.Second},
+		{Line: "for i in 1 2\ndo echo \\$i\ndone", Time: time.Unix(200, 0)},
+		{Line: "echo héllo \x83", Time: time.Unix(300, 0)},
+	}
+	for _, f := range shellFormats {
+		var b bytes.Buffer
+		if err := f.write(&b, entries); err != nil {
+			t.Fatal(f.name, err)
+		}
+		got, err := f.read(&b)
+		if err != nil {
+			t.Fatal(f.name, err)
+		}
+		if len(got) != len(entries) {
+			t.Fatalf("%s: got %d entries, want %d", f.name, len(got), len(entries))
+		}
+		for i, e := range entries {
+			if got[i].Line != e.Line || !got[i].Time.Equal(e.Time) {
+				t.Errorf("%s: entry %d is %q at %v, want %q at %v", f.name, i, got[i].Line, got[i].Time, e.Line, e.Time)
+			}
+		}
+		if f.name == "zsh" && got[0].Duration != 2*time.Second {
+			t.Errorf("zsh: duration is %v", got[0].Duration)
+		}
+	}
+}
+
+func TestShellHistoryRoundTripWithoutTime(t *testing.T) {
+	// entries without a time are not taken for the continuation of the previous one
+	entries := []uniline.HistoryEntry{
+		{Line: "first"},
+		{Line: "second", Time: time.Unix(100, 0)},
+		{Line: "third"},
+		{Line: "fourth", Time: time.Unix(200, 0)},
+	}
+	for _, f := range shellFormats {
+		var b bytes.Buffer
+		if err := f.write(&b, entries); err != nil {
+			t.Fatal(f.name, err)
+		}
+		got, err := f.read(&b)
+		if err != nil {
+			t.Fatal(f.name, err)
+		}
+		var lines []string
+		for _, e := range got {
+			lines = append(lines, e.Line)
+		}
+		if strings.Join(lines, "|") != "first|second|third|fourth" {
+			t.Errorf("%s: got %q", f.name, lines)
+		}
+	}
+}
+
+func TestReadBashHistoryWithoutTime(t *testing.T) {
+	got, err := uniline.ReadBashHistory(strings.NewReader("a\nb\n"))
+	if err != nil || len(got) != 2 || got[0].Line != "a" || got[1].Line != "b" {
+		t.Fatalf("got %v, %v", got, err)
+	}
+}
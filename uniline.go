@@ -5,9 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"unicode"
 
-	"github.com/tiborvass/uniline/ansi"
 	"golang.org/x/crypto/ssh/terminal"
 )
 
@@ -24,7 +22,41 @@ func defaultOnInterrupt(s *Scanner) (more bool) {
 type Scanner struct {
 	*Core
 	onInterrupt func(*Scanner) (more bool)
-	km          Keymap
+	tty         *os.File     // opened by NewTTYScanner
+	shared      *historyFile // c.f. ShareHistory
+}
+
+// Terminal is the TTY a Scanner edits lines on.
+// Any input implementing Terminal is considered interactive by NewScanner, which makes it possible to drive a Scanner
+// with an emulated terminal (c.f. package uniline/vtest).
+type Terminal interface {
+	// MakeRaw puts the terminal in raw mode and returns a function restoring its previous state.
+	MakeRaw() (restore func(), err error)
+	// Width returns the number of columns of the terminal.
+	Width() (int, error)
+	// Height returns the number of rows of the terminal.
+	Height() (int, error)
+}
+
+// fdTerminal is the Terminal behind a file descriptor.
+type fdTerminal uintptr
+
+func (fd fdTerminal) MakeRaw() (func(), error) {
+	state, err := terminal.MakeRaw(int(fd))
+	if err != nil {
+		return nil, err
+	}
+	return func() { terminal.Restore(int(fd), state) }, nil
+}
+
+func (fd fdTerminal) Width() (int, error) {
+	width, _, err := terminal.GetSize(int(fd))
+	return width, err
+}
+
+func (fd fdTerminal) Height() (int, error) {
+	_, height, err := terminal.GetSize(int(fd))
+	return height, err
 }
 
 type blackhole struct{}
@@ -35,6 +67,50 @@ func (*blackhole) Write(p []byte) (n int, err error) {
 	return len(p), nil
 }
 
+// BellStyle defines how the Scanner signals the user that an action is not possible.
+type BellStyle int
+
+const (
+	BellAudible BellStyle = iota // ring the terminal bell
+	BellNone                     // do nothing
+)
+
+// Config holds the settings of a Scanner.
+// The zero value of each field selects the default.
+type Config struct {
+	// Input is where lines are read from, os.Stdin by default.
+	Input io.Reader
+	// Output is where the edited line is rendered. It defaults to Input, if Input is a TTY.
+	// In dumb-mode, nothing is rendered.
+	Output io.Writer
+	// Keymap defaults to DefaultKeymap().
+	Keymap Keymap
+	// OnInterrupt is called on Ctrl-C, c.f. DefaultScanner for the default behavior.
+	OnInterrupt func(s *Scanner) (more bool)
+	// Completer provides the candidates for tab completion. Tab completion is disabled if nil.
+	Completer Completer
+	// History stores the entries of history, in memory by default (c.f. MemoryHistory).
+	History History
+	// HistoryExpansion enables bash's history expansion (e.g. !!, !$ or ^old^new) of the line when pressing Enter.
+	// The line is not submitted if the expansion fails.
+	// Regardless of this setting, the expansion can be performed on demand with Meta-^ (c.f. Core.ExpandHistory).
+	HistoryExpansion bool
+	// HistoryLimit is the maximum number of lines kept in history, the oldest being evicted first. 0 means no limit.
+	HistoryLimit int
+	// HistoryControl selects which lines are left out of history.
+	HistoryControl HistoryControl
+	// HistoryFilter, if not nil, reports whether a line should be recorded in history.
+	HistoryFilter func(line string) bool
+	// Words selects the words used when moving or cutting by words, delimited by spaces by default.
+	Words WordStyle
+	// IsWordChar, if not nil, reports whether a rune is part of a word, overriding Words.
+	IsWordChar func(r rune) bool
+	// Bell defaults to BellAudible.
+	Bell BellStyle
+	// Term is the terminal type, overriding the TERM environment variable.
+	Term string
+}
+
 // DefaultScanner returns a ready-to-use default Scanner.
 //
 // The input is set to os.Stdin (which is also the output if it's a TTY).
@@ -43,50 +119,137 @@ func (*blackhole) Write(p []byte) (n int, err error) {
 //
 // Note: equivalent to NewScanner(nil, nil, nil, nil)
 func DefaultScanner() *Scanner {
-	return NewScanner(nil, os.Stdout, nil, nil)
+	return NewScanner(nil, nil, nil, nil)
 }
 
 // NewScanner returns a ready-to-use Scanner with configurable settings.
 //
-// NewScanner also detects if ANSI-mode is available to let the user edit the input line. If it is not available, it falls back to a dumb-mode
-// where scanning is using directly a bufio.Scanner using bufio.ScanLines.
-//
 // Any parameter can be nil in which case the defaults are used (c.f. DefaultScanner).
 //
-// In order to have a good line editing experience, input should be an *os.File with the same file descriptor as output
+// Note: equivalent to NewScannerWithConfig(Config{Input: input, Output: output, OnInterrupt: onInterrupt, Keymap: km})
 func NewScanner(input io.Reader, output io.Writer, onInterrupt func(s *Scanner) (more bool), km Keymap) *Scanner {
+	return NewScannerWithConfig(Config{Input: input, Output: output, OnInterrupt: onInterrupt, Keymap: km})
+}
+
+// NewScannerWithConfig returns a ready-to-use Scanner with the settings in cfg.
+//
+// NewScannerWithConfig also detects if ANSI-mode is available to let the user edit the input line. If it is not available, it falls back to a dumb-mode
+// where scanning is using directly a bufio.Scanner using bufio.ScanLines.
+//
+// In order to have a good line editing experience, the input should be a TTY: either an *os.File or an implementation of Terminal.
+// Unless an output is provided, the input is also the output. The output can be any io.Writer, but since the line is rendered
+// with ANSI codes, it should eventually reach the same terminal as the input.
+func NewScannerWithConfig(cfg Config) *Scanner {
+	input := cfg.Input
 	if input == nil {
 		input = os.Stdin
 	}
+	onInterrupt := cfg.OnInterrupt
 	if onInterrupt == nil {
 		onInterrupt = defaultOnInterrupt
 	}
+	if cfg.History == nil {
+		cfg.History = new(MemoryHistory)
+	}
+	km := cfg.Keymap
 	if km == nil {
 		km = DefaultKeymap()
 	}
+	t := cfg.Term
+	if len(t) == 0 {
+		t = os.Getenv("TERM")
+	}
 
-	s := &Scanner{&Core{input: input, output: devNull, dumb: true}, onInterrupt, km}
+	s := &Scanner{Core: &Core{
+		input:      input,
+		output:     devNull,
+		dumb:       true,
+		completer:  cfg.Completer,
+		isWordChar: cfg.IsWordChar,
+		wordStyle:  cfg.Words,
+		bell:       cfg.Bell,
+		km:         km,
+
+		historyExpansion: cfg.HistoryExpansion,
+		history: history{
+			historyPolicy: historyPolicy{
+				limit:   cfg.HistoryLimit,
+				control: cfg.HistoryControl,
+				filter:  cfg.HistoryFilter,
+			},
+			saved: cfg.History,
+		},
+	}, onInterrupt: onInterrupt}
+
+	if term, ok := input.(Terminal); ok {
+		output := cfg.Output
+		if output == nil {
+			output, _ = input.(io.Writer)
+		}
+		if output == nil || (len(cfg.Term) > 0 && isDumb(cfg.Term)) {
+			return s
+		}
+		s.output = output
+		s.term = term
+		s.dumb = false
+		return s
+	}
 
 	f, ok := input.(*os.File)
 	if !ok {
 		return s
 	}
 
-	if output != nil {
-		_, ok := output.(*os.File)
-		if !ok {
-			return s
-		}
+	fd := f.Fd()
+	if !terminal.IsTerminal(int(fd)) || len(t) == 0 || isDumb(t) {
+		return s
 	}
-	s.output = input.(io.Writer) // does not panic, since *os.File implements io.Writer
 
-	fd := f.Fd()
-	s.fd = &fd
-	t := os.Getenv("TERM")
-	s.dumb = !terminal.IsTerminal(int(fd)) || len(t) == 0 || t == "dumb" || t == "cons25"
+	// keys are read from input, but the line can be rendered anywhere, e.g. to a writer recording the output.
+	s.output = cfg.Output
+	if s.output == nil {
+		s.output = f
+	}
+	s.term = fdTerminal(fd)
+	s.dumb = false
 	return s
 }
 
+// NewTTYScanner returns a Scanner with the settings in cfg, reading from os.Stdin if it is a TTY, or from /dev/tty otherwise.
+// This allows to interactively edit lines even if os.Stdin is redirected, e.g. in `cat data | mytool`.
+// cfg.Input is ignored.
+//
+// The returned Scanner should be closed with Close once it is no longer used.
+func NewTTYScanner(cfg Config) (*Scanner, error) {
+	if terminal.IsTerminal(int(os.Stdin.Fd())) {
+		cfg.Input = os.Stdin
+		return NewScannerWithConfig(cfg), nil
+	}
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Input = tty
+	s := NewScannerWithConfig(cfg)
+	s.tty = tty
+	return s, nil
+}
+
+// Close closes the TTY opened by NewTTYScanner, if any.
+func (s *Scanner) Close() error {
+	if s.tty == nil {
+		return nil
+	}
+	err := s.tty.Close()
+	s.tty = nil
+	return err
+}
+
+// isDumb reports whether the terminal type t does not support the ANSI codes needed to edit a line.
+func isDumb(t string) bool {
+	return t == "dumb" || t == "cons25"
+}
+
 // Scan reads a line from the provided input and makes it available via Scanner.Bytes() and Scanner.Text().
 // It returns a boolean indicating whether there can be more lines retrieved or if scanning has ended.
 //
@@ -126,16 +289,20 @@ func (s *Scanner) Scan(prompt string) (more bool) {
 	}()
 
 	// no need to initialize internal scanner more than once
+	// note: bufio.Scanner does not allow changing the split function after scanning started.
 	if s.scanner == nil {
 		s.scanner = bufio.NewScanner(s.input)
+		if s.dumb {
+			s.scanner.Split(bufio.ScanLines)
+		} else {
+			s.scanner.Split(bufio.ScanRunes)
+		}
 	}
 
 	s.prompt = textFromString(prompt)
 	s.stop = false
 
 	if s.dumb {
-		s.scanner.Split(bufio.ScanLines)
-
 		if _, err := fmt.Fprint(s.output, string(s.prompt.bytes)); err != nil {
 			panic(err)
 		}
@@ -150,86 +317,50 @@ func (s *Scanner) Scan(prompt string) (more bool) {
 		// continue scanning if no error
 		return s.err == nil
 	}
-	state, err := terminal.MakeRaw(int(*s.fd))
+	var err error
+	s.restore, err = s.term.MakeRaw()
 	if err != nil {
 		panic(err)
 	}
 	defer func() {
-		terminal.Restore(int(*s.fd), state)
+		// the terminal may have been set to raw mode again, c.f. EditInEditor
+		s.restore()
 	}()
-	winWidth, _, err := terminal.GetSize(int(*s.fd))
+	winWidth, err := s.term.Width()
+	if err != nil {
+		panic(err)
+	}
+	winHeight, err := s.term.Height()
 	if err != nil {
 		panic(err)
 	}
 
 	s.buf = text{}
 	s.pos = position{}
+	s.mark = markState{}
 	s.cols = int(winWidth)
+	s.rows = int(winHeight)
 
-	// create new empty temporary element in History
-	s.history.tmp = append(s.history.tmp, "")
-	// set History Index to this newly created empty element
-	s.history.index = len(s.history.tmp) - 1
+	// set History Index to the current line, right after the most recent element of History
+	s.history.edits = nil
+	s.history.index = s.history.saved.Len()
 
 	s.output.Write(s.prompt.bytes)
-	s.scanner.Split(bufio.ScanRunes)
 
-	var p []byte
-
-	for !s.stop && s.scanner.Scan() {
-
-		var r rune
-
-		var isCompleteAnsiCode = func() (done bool) {
-			key := ansi.Code(p)
-			scanFun, ok := s.km[key]
-			if ok {
-				if scanFun == nil {
-					return false
-				}
-				scanFun(s.Core)
-			}
-			return true
+	if s.history.hasNext {
+		s.history.hasNext = false
+		if s.history.next < s.history.saved.Len() {
+			s.historyJump(s.history.next)
+			s.Refresh()
 		}
+	}
 
-		if p == nil {
-			// In case where p is either one-rune long or it is the first byte of a long command
-
-			p = s.scanner.Bytes()
-			r = getRune(string(p))
-
-			// if printable, then it's not a command
-			if unicode.IsPrint(r) {
-				s.Insert(charFromRune(r))
-				// moving on to next rune
-				p = nil
-				continue
-			}
-
-			//Debug("r: %v", r)
-
-			if isCompleteAnsiCode() {
-				// moving on to next rune
-				p = nil
-			}
-
-			// handle special case for Clipboard
-			if r != 23 && r != 21 && r != 11 {
-				// not Ctrl-W, Ctrl-U, or Ctrl-K
-				// thus consider the Clipboard as complete and stop gluing Clipboard parts together
-				s.clipboard.partial = false
-			}
-
-		} else {
-			// In the case where p is an escape sequence, add current bytes to previous and try a lookup
-
-			p = append(p, s.scanner.Bytes()...)
-			//Debug("p: %v", p)
-			if isCompleteAnsiCode() {
-				p = nil
-				//Debug("done")
-			}
+	for !s.stop {
+		key, ok := s.readKey()
+		if !ok {
+			break
 		}
+		s.dispatch(key)
 	}
 
 	s.err = s.scanner.Err()
@@ -257,15 +388,3 @@ func (s *Scanner) Text() string {
 func (s *Scanner) Bytes() []byte {
 	return s.buf.bytes
 }
-
-// trick to get the first rune of a string without utf8 package
-func getRune(str string) rune {
-	var i int
-	var r rune
-	for i, r = range str {
-		if i > 0 {
-			panic("ScanRunes is supposed to scan one rune at a time, but received more than one")
-		}
-	}
-	return r
-}
@@ -0,0 +1,108 @@
+package uniline_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/tiborvass/uniline"
+	"github.com/tiborvass/uniline/ansi"
+	"github.com/tiborvass/uniline/vtest"
+)
+
+// snapshotTerminal records the screen and the cursor when all the keystrokes have been read,
+// i.e. before Scan erases what was drawn below the line.
+type snapshotTerminal struct {
+	*vtest.Terminal
+	screen []string
+	x, y   int
+}
+
+func (t *snapshotTerminal) Read(p []byte) (int, error) {
+	n, err := t.Terminal.Read(p)
+	if err == io.EOF && t.screen == nil {
+		t.screen = t.Screen()
+		t.x, t.y = t.Cursor()
+	}
+	return n, err
+}
+
+// scan scans a line typed with keys on a 40x10 terminal, with the settings of cfg.
+func scan(t *testing.T, cfg uniline.Config, keys ...ansi.Code) (*uniline.Scanner, *snapshotTerminal) {
+	t.Helper()
+	term := &snapshotTerminal{Terminal: vtest.New(40, 10)}
+	cfg.Input = term
+	s := uniline.NewScannerWithConfig(cfg)
+	term.Type(keys...)
+	s.Scan("> ")
+	return s, term
+}
+
+func TestEditing(t *testing.T) {
+	s, term := scan(t, uniline.Config{}, "foo", ansi.LEFT, "X", ansi.CTRL_A, "<", ansi.CTRL_E, ">", ansi.CARRIAGE_RETURN)
+	if s.Text() != "<foXo>" || term.Line(0) != "> <foXo>" {
+		t.Fatalf("got %q, screen %q", s.Text(), term.Screen())
+	}
+}
+
+func TestConfig(t *testing.T) {
+	cfg := uniline.Config{HistoryLimit: 2, Bell: uniline.BellNone}
+	term := vtest.New(40, 10)
+	cfg.Input = term
+	s := uniline.NewScannerWithConfig(cfg)
+	for _, l := range []string{"a", "b", "c"} {
+		s.AddToHistory(l)
+	}
+	term.Type(ansi.UP, ansi.UP, ansi.UP, ansi.CARRIAGE_RETURN)
+	s.Scan("> ")
+	if s.Text() != "b" || term.Bells() != 0 {
+		t.Fatalf("got %q with %d bells", s.Text(), term.Bells())
+	}
+
+	s, _ = scan(t, uniline.Config{IsWordChar: func(r rune) bool { return r != '/' }}, "/usr/local/bin", ansi.CTRL_W, ansi.CARRIAGE_RETURN)
+	if s.Text() != "/usr/local/" {
+		t.Fatalf("got %q with IsWordChar", s.Text())
+	}
+
+	// nothing is rendered on a dumb terminal
+	s, term2 := scan(t, uniline.Config{Term: "dumb"}, "foo\n")
+	if s.Text() != "foo" || term2.Line(0) != "" {
+		t.Fatalf("got %q, screen %q", s.Text(), term2.Screen())
+	}
+}
+
+func TestOutput(t *testing.T) {
+	// the line is rendered to the output, while the keys are typed on the input
+	in, out := vtest.New(40, 10), vtest.New(40, 10)
+	s := uniline.NewScannerWithConfig(uniline.Config{Input: in, Output: out})
+	in.Type("foo", ansi.LEFT, "X", ansi.CARRIAGE_RETURN)
+	if !s.Scan("> ") || s.Text() != "foXo" {
+		t.Fatalf("got %q", s.Text())
+	}
+	if out.Line(0) != "> foXo" || in.Line(0) != "" {
+		t.Fatalf("output %q, input %q", out.Screen(), in.Screen())
+	}
+
+	// nothing is rendered in dumb mode
+	in, out = vtest.New(40, 10), vtest.New(40, 10)
+	s = uniline.NewScannerWithConfig(uniline.Config{Input: in, Output: out, Term: "dumb"})
+	in.Type("foo\n")
+	if !s.Scan("> ") || s.Text() != "foo" || out.Line(0) != "" {
+		t.Fatalf("got %q, output %q", s.Text(), out.Screen())
+	}
+}
+
+func TestNewTTYScanner(t *testing.T) {
+	if err := uniline.NewScanner(vtest.New(40, 10), nil, nil, nil).Close(); err != nil {
+		t.Fatal(err)
+	}
+	s, err := uniline.NewTTYScanner(uniline.Config{})
+	if err != nil {
+		t.Skip("no TTY:", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal("second Close:", err)
+	}
+}
@@ -5,7 +5,12 @@
 
 package uniline
 
-import "github.com/shinichy/go-wcwidth"
+import (
+	"fmt"
+	"unicode"
+
+	"github.com/shinichy/go-wcwidth"
+)
 
 // char represents a character in the terminal screen
 // Its size is defined as follows:
@@ -19,9 +24,31 @@ type char struct {
 }
 
 func charFromRune(r rune) char {
+	if unicode.IsControl(r) {
+		c := char{[]byte(string(r)), r, 0}
+		c.colLen = len(c.view())
+		return c
+	}
 	return char{[]byte(string(r)), r, wcwidth.WcwidthUcs(r)}
 }
 
+// isCaret reports whether r is a control character drawn in caret notation, e.g. ^A for Ctrl-A.
+func isCaret(r rune) bool {
+	return r < ' ' || r == '\x7f'
+}
+
+// view returns how c is drawn in the terminal.
+// The C1 control characters, which have no caret notation, are drawn as their hexadecimal code, e.g. <85>.
+func (c char) view() []byte {
+	switch {
+	case isCaret(c.r):
+		return []byte{'^', byte(c.r) ^ 0x40}
+	case unicode.IsControl(c.r):
+		return []byte(fmt.Sprintf("<%02x>", c.r))
+	}
+	return c.p
+}
+
 func (c char) Clone() char {
 	b := make([]byte, len(c.p))
 	copy(b, c.p)
@@ -110,6 +137,29 @@ func (t text) Slice(segment ...position) text {
 	return t
 }
 
+// PositionAt returns the position of the character starting at byte offset i.
+func (t text) PositionAt(i int) position {
+	var pos position
+	for pos.bytes < i && pos.runes < len(t.chars) {
+		pos = pos.Add(t.chars[pos.runes])
+	}
+	return pos
+}
+
+// RunePosition returns the position of the ith character.
+func (t text) RunePosition(i int) position {
+	return position{}.Add(t.chars[:i]...)
+}
+
+// view returns how t is drawn in the terminal, c.f. char.view.
+func (t text) view() []byte {
+	p := make([]byte, 0, len(t.bytes))
+	for _, c := range t.chars {
+		p = append(p, c.view()...)
+	}
+	return p
+}
+
 func (t text) Clone() text {
 	chars := make([]char, len(t.chars))
 	for i, c := range t.chars {
@@ -0,0 +1,21 @@
+package vtest_test
+
+import (
+	"fmt"
+
+	"github.com/tiborvass/uniline"
+	"github.com/tiborvass/uniline/ansi"
+	"github.com/tiborvass/uniline/vtest"
+)
+
+func Example() {
+	term := vtest.New(80, 24)
+	s := uniline.NewScanner(term, nil, nil, nil)
+	term.Type("foo", ansi.LEFT, "X", ansi.CARRIAGE_RETURN)
+	s.Scan("> ")
+	fmt.Println(s.Text())
+	fmt.Println(term.Line(0))
+	// Output:
+	// foXo
+	// > foXo
+}
@@ -0,0 +1,285 @@
+/*
+Package vtest provides an in-memory virtual terminal to test uniline Scanners and custom Keymaps.
+
+A Terminal keeps a VT100 screen grid up to date by interpreting the ANSI codes written to it,
+and serves the keystrokes queued with Type as input:
+
+	term := vtest.New(80, 24)
+	s := uniline.NewScanner(term, nil, nil, nil)
+	term.Type("foo", ansi.LEFT, "X", ansi.CARRIAGE_RETURN)
+	s.Scan("> ")
+	// s.Text() == "foXo" and term.Line(0) == "> foXo"
+
+Once all the queued keystrokes have been read, the Terminal reports io.EOF,
+so keystrokes for all the lines to be scanned should be queued beforehand.
+*/
+package vtest
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/shinichy/go-wcwidth"
+	"github.com/tiborvass/uniline/ansi"
+)
+
+// Terminal is an in-memory VT100 terminal implementing uniline.Terminal.
+type Terminal struct {
+	cols, rows int
+	grid       [][]rune // 0 marks the right half of a wide character
+	x, y       int
+	wrap       bool // whether the next character goes to the next line, since the last one filled the row
+	bells      int
+	raw        bool
+
+	input   bytes.Buffer
+	pending []byte // incomplete escape sequence or UTF-8 encoding from the previous Write
+}
+
+// New returns a blank Terminal of the given size, with the cursor in the top left corner.
+func New(cols, rows int) *Terminal {
+	t := &Terminal{cols: cols, rows: rows}
+	t.clear()
+	return t
+}
+
+// Type queues keystrokes to be read by the Scanner.
+func (t *Terminal) Type(keys ...ansi.Code) {
+	for _, k := range keys {
+		t.input.WriteString(string(k))
+	}
+}
+
+// Read implements io.Reader by returning the queued keystrokes, or io.EOF if there are none left.
+func (t *Terminal) Read(p []byte) (n int, err error) {
+	return t.input.Read(p)
+}
+
+// MakeRaw implements uniline.Terminal.
+// Outside of raw mode, a newline also moves the cursor to the left edge.
+func (t *Terminal) MakeRaw() (restore func(), err error) {
+	raw := t.raw
+	t.raw = true
+	return func() { t.raw = raw }, nil
+}
+
+// Width implements uniline.Terminal.
+func (t *Terminal) Width() (int, error) {
+	return t.cols, nil
+}
+
+// Height implements uniline.Terminal.
+func (t *Terminal) Height() (int, error) {
+	return t.rows, nil
+}
+
+// Write implements io.Writer by interpreting p as terminal output.
+func (t *Terminal) Write(p []byte) (n int, err error) {
+	buf := append(t.pending, p...)
+	t.pending = nil
+	for len(buf) > 0 {
+		var size int
+		switch c := buf[0]; {
+		case c == '\x1b':
+			size = t.escape(buf)
+		case c < ' ' || c == '\x7f':
+			t.control(c)
+			size = 1
+		default:
+			if !utf8.FullRune(buf) {
+				break
+			}
+			var r rune
+			r, size = utf8.DecodeRune(buf)
+			t.put(r)
+		}
+		if size == 0 {
+			t.pending = append([]byte(nil), buf...)
+			break
+		}
+		buf = buf[size:]
+	}
+	return len(p), nil
+}
+
+// escape interprets the escape sequence at the beginning of p and returns its length, or 0 if it is incomplete.
+func (t *Terminal) escape(p []byte) int {
+	if len(p) < 2 {
+		return 0
+	}
+	if p[1] != '[' {
+		// not a control sequence: ignore ESC and the following byte
+		return 2
+	}
+	i := 2
+	for i < len(p) && (p[i] >= '0' && p[i] <= '9' || p[i] == ';' || p[i] == '?') {
+		i++
+	}
+	if i == len(p) {
+		return 0
+	}
+	params := strings.Split(string(p[2:i]), ";")
+	// arg returns the nth parameter, or def if it is missing or 0, as VT100 does
+	arg := func(n, def int) int {
+		if n < len(params) {
+			if v, err := strconv.Atoi(params[n]); err == nil && v > 0 {
+				return v
+			}
+		}
+		return def
+	}
+	t.wrap = false
+	switch p[i] {
+	case 'A':
+		t.y -= arg(0, 1)
+	case 'B':
+		t.y += arg(0, 1)
+	case 'C':
+		t.x += arg(0, 1)
+	case 'D':
+		t.x -= arg(0, 1)
+	case 'G':
+		t.x = arg(0, 1) - 1
+	case 'H':
+		t.y, t.x = arg(0, 1)-1, arg(1, 1)-1
+	case 'J':
+		switch arg(0, 0) {
+		case 0:
+			t.eraseRight()
+			for y := t.y + 1; y < t.rows; y++ {
+				t.grid[y] = t.blankRow()
+			}
+		case 2:
+			t.clear()
+		}
+	case 'K':
+		t.eraseRight()
+	}
+	t.clamp()
+	return i + 1
+}
+
+func (t *Terminal) control(c byte) {
+	switch c {
+	case '\a':
+		t.bells++
+	case '\b':
+		if t.x > 0 {
+			t.x--
+		}
+	case '\r':
+		t.x = 0
+	case '\n':
+		if !t.raw {
+			t.x = 0
+		}
+		t.lineFeed()
+	}
+	t.wrap = false
+}
+
+func (t *Terminal) put(r rune) {
+	w := wcwidth.WcwidthUcs(r)
+	if w <= 0 {
+		return
+	}
+	if t.wrap || t.x+w > t.cols {
+		t.x = 0
+		t.lineFeed()
+	}
+	t.wrap = false
+	t.grid[t.y][t.x] = r
+	if w == 2 {
+		t.grid[t.y][t.x+1] = 0
+	}
+	t.x += w
+	if t.x == t.cols {
+		// like VT100, keep the cursor on the last column until the next character is printed
+		t.x = t.cols - 1
+		t.wrap = true
+	}
+}
+
+func (t *Terminal) lineFeed() {
+	if t.y < t.rows-1 {
+		t.y++
+		return
+	}
+	copy(t.grid, t.grid[1:])
+	t.grid[t.rows-1] = t.blankRow()
+}
+
+func (t *Terminal) eraseRight() {
+	for x := t.x; x < t.cols; x++ {
+		t.grid[t.y][x] = ' '
+	}
+}
+
+func (t *Terminal) clear() {
+	t.grid = make([][]rune, t.rows)
+	for y := range t.grid {
+		t.grid[y] = t.blankRow()
+	}
+	t.x, t.y = 0, 0
+}
+
+func (t *Terminal) blankRow() []rune {
+	row := make([]rune, t.cols)
+	for x := range row {
+		row[x] = ' '
+	}
+	return row
+}
+
+func (t *Terminal) clamp() {
+	if t.x < 0 {
+		t.x = 0
+	}
+	if t.x >= t.cols {
+		t.x = t.cols - 1
+	}
+	if t.y < 0 {
+		t.y = 0
+	}
+	if t.y >= t.rows {
+		t.y = t.rows - 1
+	}
+}
+
+// Line returns the visible content of row y, without trailing spaces.
+func (t *Terminal) Line(y int) string {
+	var b strings.Builder
+	for _, r := range t.grid[y] {
+		if r != 0 {
+			b.WriteRune(r)
+		}
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+// Screen returns the visible content of all the rows, without trailing spaces nor trailing empty rows.
+func (t *Terminal) Screen() []string {
+	lines := make([]string, t.rows)
+	for y := range lines {
+		lines[y] = t.Line(y)
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Cursor returns the position of the cursor, starting from 0.
+func (t *Terminal) Cursor() (x, y int) {
+	return t.x, t.y
+}
+
+// Bells returns the number of times the bell rang.
+func (t *Terminal) Bells() int {
+	return t.bells
+}
+
+var _ io.ReadWriter = (*Terminal)(nil)
@@ -0,0 +1,88 @@
+package vtest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	term := New(4, 3)
+	term.Write([]byte("abcd"))
+	// the cursor stays on the last column until the next character
+	if x, y := term.Cursor(); x != 3 || y != 0 {
+		t.Fatalf("cursor at %d,%d, want 3,0", x, y)
+	}
+	term.Write([]byte("ef"))
+	if got, want := term.Screen(), []string{"abcd", "ef"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if x, y := term.Cursor(); x != 2 || y != 1 {
+		t.Fatalf("cursor at %d,%d, want 2,1", x, y)
+	}
+}
+
+func TestScroll(t *testing.T) {
+	term := New(4, 2)
+	term.Write([]byte("a\r\nb\r\nc"))
+	if got, want := term.Screen(), []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestWideRunes(t *testing.T) {
+	term := New(5, 2)
+	term.Write([]byte("a日本"))
+	if x, _ := term.Cursor(); x != 4 {
+		t.Fatalf("cursor at column %d, want 4", x)
+	}
+	// a wide rune not fitting on the row goes to the next one
+	term.Write([]byte("語"))
+	if got, want := term.Screen(), []string{"a日本", "語"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestErase(t *testing.T) {
+	term := New(6, 3)
+	term.Write([]byte("abcdef\r\nghijkl\r\nmnopqr"))
+	term.Write([]byte("\x1b[2;3H\x1b[K"))
+	if got, want := term.Screen(), []string{"abcdef", "gh", "mnopqr"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("after K: got %q, want %q", got, want)
+	}
+	term.Write([]byte("\x1b[1;4H\x1b[J"))
+	if got, want := term.Screen(), []string{"abc"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("after J: got %q, want %q", got, want)
+	}
+	term.Write([]byte("\x1b[2J"))
+	if got := term.Screen(); len(got) != 0 {
+		t.Fatalf("after 2J: got %q", got)
+	}
+	if x, y := term.Cursor(); x != 0 || y != 0 {
+		t.Fatalf("cursor at %d,%d, want 0,0", x, y)
+	}
+}
+
+func TestSplitWrites(t *testing.T) {
+	term := New(10, 2)
+	term.Write([]byte("abcdef\x1b["))
+	term.Write([]byte("3"))
+	term.Write([]byte("DX\xe6"))
+	term.Write([]byte("\x97\xa5"))
+	if got, want := term.Line(0), "abcX日"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestBellsAndRaw(t *testing.T) {
+	term := New(10, 3)
+	restore, _ := term.MakeRaw()
+	term.Write([]byte("ab\a\ncd"))
+	restore()
+	term.Write([]byte("\nef"))
+	if got, want := term.Screen(), []string{"ab", "  cd", "ef"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if term.Bells() != 1 {
+		t.Fatalf("got %d bells, want 1", term.Bells())
+	}
+}
@@ -0,0 +1,173 @@
+package uniline
+
+import "unicode"
+
+// WordStyle defines the words of the line for the commands moving or cutting by words.
+type WordStyle int
+
+const (
+	WordsSpaceDelimited WordStyle = iota // words delimited by spaces
+	WordsAlphanumeric                    // letters and digits, like in readline
+	WordsShell                           // shell-like arguments, which can contain quoted or escaped spaces, and path components
+)
+
+// words reports, for each rune of the line, whether it is part of a word, c.f. Config.Words and Config.IsWordChar.
+func (core *Core) words() []bool {
+	mask := make([]bool, len(core.buf.chars))
+	if core.isWordChar == nil && core.wordStyle == WordsShell {
+		var quote rune
+		escaped := false
+		for i, c := range core.buf.chars {
+			switch {
+			case escaped:
+				escaped = false
+				mask[i] = true
+			case c.r == '/':
+				// path components are words of their own, even quoted
+			case c.r == '\\' && quote != '\'':
+				escaped = true
+				mask[i] = true
+			case quote != 0:
+				if c.r == quote {
+					quote = 0
+				}
+				mask[i] = true
+			case c.r == '\'' || c.r == '"':
+				quote = c.r
+				mask[i] = true
+			default:
+				mask[i] = !unicode.IsSpace(c.r)
+			}
+		}
+		return mask
+	}
+	for i, c := range core.buf.chars {
+		mask[i] = core.wordChar(c.r)
+	}
+	return mask
+}
+
+// wordChar reports whether r is part of a word, regardless of its context.
+func (core *Core) wordChar(r rune) bool {
+	switch {
+	case core.isWordChar != nil:
+		return core.isWordChar(r)
+	case core.wordStyle == WordsAlphanumeric:
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	default:
+		return !unicode.IsSpace(r)
+	}
+}
+
+// forwardWord returns the index of the character following the end of the next word from the ith character, c.f. MoveWordRight.
+func forwardWord(words []bool, i int) int {
+	for i < len(words) && !words[i] {
+		i++
+	}
+	for i < len(words) && words[i] {
+		i++
+	}
+	return i
+}
+
+// backwardWord returns the index of the first character of the previous word before the ith character, c.f. MoveWordLeft.
+func backwardWord(words []bool, i int) int {
+	for i > 0 && !words[i-1] {
+		i--
+	}
+	for i > 0 && words[i-1] {
+		i--
+	}
+	return i
+}
+
+// forwardWords is forwardWord, n times.
+func forwardWords(words []bool, i, n int) int {
+	for ; n > 0; n-- {
+		i = forwardWord(words, i)
+	}
+	return i
+}
+
+// backwardWords is backwardWord, n times.
+func backwardWords(words []bool, i, n int) int {
+	for ; n > 0; n-- {
+		i = backwardWord(words, i)
+	}
+	return i
+}
+
+// UpcaseWord converts the letters from the cursor to the end of the word to upper case, and moves the cursor after the word.
+// With a numeric argument (c.f. Count), as many words are converted, the previous ones if it is negative, without moving the cursor.
+func (core *Core) UpcaseWord() {
+	core.caseWords(unicode.ToUpper, unicode.ToUpper)
+}
+
+// DowncaseWord converts the letters from the cursor to the end of the word to lower case, and moves the cursor after the word.
+// The numeric argument is handled like in UpcaseWord.
+func (core *Core) DowncaseWord() {
+	core.caseWords(unicode.ToLower, unicode.ToLower)
+}
+
+// CapitalizeWord converts the first letter from the cursor to title case and the following ones to lower case,
+// up to the end of the word, and moves the cursor after the word.
+// The numeric argument is handled like in UpcaseWord.
+func (core *Core) CapitalizeWord() {
+	core.caseWords(unicode.ToTitle, unicode.ToLower)
+}
+
+// caseWords maps the first letter of each word from the cursor to the end of the Count-th word with first, the following ones with rest.
+func (core *Core) caseWords(first, rest func(rune) rune) {
+	words := core.words()
+	n := core.Count()
+	from, to := core.pos.runes, core.pos.runes
+	if n < 0 {
+		from = backwardWords(words, from, -n)
+	} else {
+		to = forwardWords(words, to, n)
+	}
+	if from == to {
+		core.Bell()
+		return
+	}
+	var t text
+	letters := 0
+	for i, c := range core.buf.chars[from:to] {
+		if !words[from+i] {
+			letters = 0
+		} else if unicode.IsLetter(c.r) {
+			if letters == 0 {
+				c = charFromRune(first(c.r))
+			} else {
+				c = charFromRune(rest(c.r))
+			}
+			letters++
+		}
+		t = t.AppendChar(c)
+	}
+	pos := core.pos
+	core.replace(core.buf.RunePosition(from), core.buf.RunePosition(to), t)
+	if n < 0 {
+		core.pos = core.buf.RunePosition(pos.runes)
+		core.Refresh()
+	}
+}
+
+// TransposeWords swaps the word before the cursor with the one after it, and moves the cursor after them.
+// At the end of the line, the last two words are swapped.
+func (core *Core) TransposeWords() {
+	words := core.words()
+	end2 := forwardWord(words, core.pos.runes)
+	start2 := backwardWord(words, end2)
+	start1 := backwardWord(words, start2)
+	end1 := forwardWord(words, start1)
+	if start1 == start2 || start2 < end1 {
+		core.Bell()
+		return
+	}
+	p := core.buf.RunePosition
+	t := core.buf.Slice(p(start2), p(end2)).Clone().
+		AppendText(core.buf.Slice(p(end1), p(start2))).
+		AppendText(core.buf.Slice(p(start1), p(end1)))
+	core.replace(p(start1), p(end2), t)
+}
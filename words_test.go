@@ -0,0 +1,62 @@
+package uniline_test
+
+import (
+	"testing"
+
+	"github.com/tiborvass/uniline"
+	"github.com/tiborvass/uniline/ansi"
+)
+
+func TestWordStyles(t *testing.T) {
+	line := ansi.Code(`cp "my file" /usr/local/bin`)
+	for _, tc := range []struct {
+		words uniline.WordStyle
+		keys  []ansi.Code
+		want  string
+	}{
+		{uniline.WordsSpaceDelimited, []ansi.Code{line, ansi.CTRL_W}, `cp "my file" `},
+		{uniline.WordsShell, []ansi.Code{line, ansi.CTRL_W, ansi.CTRL_W}, `cp "my file" /usr/`},
+		{uniline.WordsShell, []ansi.Code{line, ansi.CTRL_W, ansi.CTRL_W, ansi.CTRL_W, ansi.CTRL_W}, `cp `},
+		{uniline.WordsShell, []ansi.Code{`a "b c" d`, ansi.META_B, ansi.META_B, "X"}, `a X"b c" d`},
+		{uniline.WordsAlphanumeric, []ansi.Code{"foo-bar.baz", ansi.META_B, ansi.META_B, "X"}, `foo-Xbar.baz`},
+	} {
+		s, _ := scan(t, uniline.Config{Words: tc.words}, append(tc.keys, ansi.CARRIAGE_RETURN)...)
+		if s.Text() != tc.want {
+			t.Errorf("%q: got %q, want %q", tc.keys, s.Text(), tc.want)
+		}
+	}
+}
+
+func TestArgumentCompleterFunc(t *testing.T) {
+	c := uniline.ArgumentCompleterFunc(func(arg string) []string { return []string{arg + " x"} })
+	for line, want := range map[string]string{
+		`ls a\ b`: `ls a\ b\ x`,
+		`ls 'a b`: `ls 'a b x`,
+		`ls "a$`:  `ls "a\$ x`,
+	} {
+		s, _ := scan(t, uniline.Config{Completer: c}, ansi.Code(line), ansi.TAB, ansi.CARRIAGE_RETURN)
+		if s.Text() != want {
+			t.Errorf("%q: got %q, want %q", line, s.Text(), want)
+		}
+	}
+}
+
+func TestCaseAndTransposition(t *testing.T) {
+	for _, tc := range []struct {
+		keys []ansi.Code
+		want string
+	}{
+		{[]ansi.Code{"héllo wörld", ansi.CTRL_A, ansi.META_U, ansi.META_C}, "HÉLLO Wörld"},
+		{[]ansi.Code{"ÉCOLE x", ansi.CTRL_A, ansi.META_F, ansi.META_B, ansi.RIGHT, ansi.META_L}, "École x"},
+		{[]ansi.Code{"aé", ansi.CTRL_T}, "éa"},
+		{[]ansi.Code{"日本x", ansi.LEFT, ansi.CTRL_T, "!"}, "日x本!"},
+		{[]ansi.Code{"one two three", ansi.META_T}, "one three two"},
+		{[]ansi.Code{"one two three", ansi.CTRL_A, ansi.META_F, ansi.META_T, "!"}, "two one! three"},
+		{[]ansi.Code{"one", ansi.META_T}, "one"},
+	} {
+		s, term := scan(t, uniline.Config{}, append(tc.keys, ansi.CARRIAGE_RETURN)...)
+		if s.Text() != tc.want || term.Line(0) != "> "+tc.want {
+			t.Errorf("%q: got %q, line %q, want %q", tc.keys, s.Text(), term.Line(0), tc.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package uniline
+
+// yankState is the state of consecutive calls to YankLastArg.
+type yankState struct {
+	index int      // index in history of the entry the inserted word comes from
+	start position // position of the inserted word
+	n     int      // index of the inserted word in the entry, from the end if negative
+}
+
+// YankLastArg inserts the last word of the previous history entry at the cursor.
+// Calling it again replaces the inserted word with the same word of the entry before, and so on.
+// With a numeric argument n (c.f. Count), the nth word is inserted instead, starting from 0, or from the end if n is negative.
+func (core *Core) YankLastArg() {
+	if core.arg.active {
+		core.yankArg(core.Count())
+	} else {
+		core.yankArg(-1)
+	}
+}
+
+// yankArg inserts the nth word (from the end if n is negative) of the previous history entry,
+// or replaces the word inserted by the previous call with the one of the entry before, at the index the previous call was given.
+func (core *Core) yankArg(n int) {
+	h := &core.history
+	i := h.saved.Len() - 1
+	if core.lastCommand == cmdYankArg {
+		i, n = core.yank.index-1, core.yank.n
+	}
+	if i < 0 {
+		core.Bell()
+		if core.lastCommand == cmdYankArg {
+			// the next call can still go back from the same entry
+			core.command = cmdYankArg
+		}
+		return
+	}
+	words := splitHistoryWords(h.saved.At(i).Line)
+	w := n
+	if w < 0 {
+		w += len(words)
+	}
+	var word text
+	if w >= 0 && w < len(words) {
+		word = textFromString(words[w])
+	}
+
+	start := core.pos
+	if core.lastCommand == cmdYankArg {
+		start = core.yank.start
+	}
+	core.replace(start, core.pos, word)
+	core.yank = yankState{index: i, start: start, n: n}
+	core.command = cmdYankArg
+}
@@ -0,0 +1,40 @@
+package uniline_test
+
+import (
+	"testing"
+
+	"github.com/tiborvass/uniline"
+	"github.com/tiborvass/uniline/ansi"
+)
+
+func TestYankLastArg(t *testing.T) {
+	h := new(uniline.MemoryHistory)
+	for _, l := range []string{"cp a 'long file'", "ls b"} {
+		h.Add(uniline.HistoryEntry{Line: l})
+	}
+	s, term := scan(t, uniline.Config{History: h}, "x ", ansi.META_DOT, ansi.META_DOT, ansi.META_DOT, " ", ansi.META_DOT, ansi.CARRIAGE_RETURN)
+	if s.Text() != "x 'long file' b" || term.Bells() != 1 {
+		t.Fatalf("got %q with %d bells", s.Text(), term.Bells())
+	}
+}
+
+func TestYankNthArg(t *testing.T) {
+	h := new(uniline.MemoryHistory)
+	for _, l := range []string{"a1 b1 c1", "a2 b2 c2"} {
+		h.Add(uniline.HistoryEntry{Line: l})
+	}
+	for _, tc := range []struct {
+		keys []ansi.Code
+		want string
+	}{
+		{[]ansi.Code{ansi.META_1, ansi.META_DOT}, "b2"},
+		// the word index is kept by the consecutive calls
+		{[]ansi.Code{ansi.META_1, ansi.META_DOT, ansi.META_DOT}, "b1"},
+		{[]ansi.Code{ansi.META_DOT, ansi.META_DOT}, "c1"},
+	} {
+		s, _ := scan(t, uniline.Config{History: h}, append(tc.keys, ansi.CARRIAGE_RETURN)...)
+		if s.Text() != tc.want {
+			t.Errorf("%q: got %q, want %q", tc.keys, s.Text(), tc.want)
+		}
+	}
+}